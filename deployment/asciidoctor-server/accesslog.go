@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// AccessLogEntry is one JSON line written to the access log for every logged request -
+// a superset of what loggingMiddleware emits to stderr, aimed at reproducing a bad
+// AsciiDoc submission after the fact rather than at routine operational tailing.
+type AccessLogEntry struct {
+	Timestamp    string `json:"timestamp"`
+	RequestID    string `json:"request_id"`
+	Method       string `json:"method"`
+	Path         string `json:"path"`
+	Status       int    `json:"status"`
+	DurationMS   int64  `json:"duration_ms"`
+	RequestBody  string `json:"request_body,omitempty"`
+	ResponseBody string `json:"response_body,omitempty"`
+}
+
+// accessLogMiddleware is an opt-in sibling to loggingMiddleware: it records the full
+// request and response body for every /convert/* call to a separate rotating file,
+// so a bad AsciiDoc submission that produced a timeout or 500 can be replayed later.
+// It is disabled (s.accessLog is nil) unless ASCIIDOCTOR_HTTP_LOG_PATH is set.
+func (s *Server) accessLogMiddleware(next http.Handler) http.Handler {
+	if s.accessLog == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		requestID := "unknown"
+		if id := r.Context().Value("request_id"); id != nil {
+			requestID = id.(string)
+		}
+
+		reqBody := s.teeRequestBody(r)
+
+		rrw := &responseReadWriter{
+			ResponseWriter: w,
+			statusCode:     http.StatusOK,
+			maxBody:        s.accessLogMaxBody,
+			textTypes:      s.gzipTypes,
+		}
+
+		next.ServeHTTP(rrw, r)
+
+		entry := AccessLogEntry{
+			Timestamp:    time.Now().UTC().Format(time.RFC3339Nano),
+			RequestID:    requestID,
+			Method:       r.Method,
+			Path:         r.URL.Path,
+			Status:       rrw.statusCode,
+			DurationMS:   time.Since(start).Milliseconds(),
+			RequestBody:  reqBody,
+			ResponseBody: rrw.bodyString(),
+		}
+
+		line, err := json.Marshal(entry)
+		if err != nil {
+			s.logger.Error("http", "Failed to encode access log entry", err, map[string]interface{}{
+				"request_id": requestID,
+				"error_type": "encoding_error",
+			})
+			return
+		}
+		line = append(line, '\n')
+		if _, err := s.accessLog.Write(line); err != nil {
+			s.logger.Error("http", "Failed to write access log entry", err, map[string]interface{}{
+				"request_id": requestID,
+				"error_type": "file_operation_error",
+			})
+		}
+	})
+}
+
+// teeRequestBody reads r.Body (truncated to maxBody for the returned string) while
+// repopulating r.Body via io.NopCloser so downstream handlers still see the full body.
+func (s *Server) teeRequestBody(r *http.Request) string {
+	if r.Body == nil {
+		return ""
+	}
+
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		r.Body = io.NopCloser(bytes.NewReader(nil))
+		return ""
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return truncateBody(body, s.accessLogMaxBody)
+}
+
+// responseReadWriter tees writes into a bounded buffer (for the access log) while
+// still forwarding every byte to the client unchanged. Teeing is skipped for a
+// binary Content-Type (an EPUB/PDF/MOBI/AZW3 artifact, or a job result download) -
+// response_body is for replaying a bad AsciiDoc submission, not for stuffing a
+// mangled binary blob into a JSON log line.
+type responseReadWriter struct {
+	http.ResponseWriter
+	statusCode int
+	maxBody    int
+	textTypes  []string
+	buf        bytes.Buffer
+}
+
+func (rrw *responseReadWriter) WriteHeader(code int) {
+	rrw.statusCode = code
+	rrw.ResponseWriter.WriteHeader(code)
+}
+
+func (rrw *responseReadWriter) Write(b []byte) (int, error) {
+	if rrw.shouldCaptureType() && rrw.buf.Len() < rrw.maxBody {
+		remaining := rrw.maxBody - rrw.buf.Len()
+		if remaining > len(b) {
+			remaining = len(b)
+		}
+		rrw.buf.Write(b[:remaining])
+	}
+	return rrw.ResponseWriter.Write(b)
+}
+
+// shouldCaptureType checks the handler's Content-Type (set before any Write, per
+// net/http convention) against rrw.textTypes, defaulting to capture when no
+// Content-Type was set at all - the same allowlist and default as
+// gzipResponseWriter.shouldCompressType in middleware.go.
+func (rrw *responseReadWriter) shouldCaptureType() bool {
+	contentType := rrw.Header().Get("Content-Type")
+	if contentType == "" {
+		return true
+	}
+	for _, t := range rrw.textTypes {
+		if strings.HasPrefix(contentType, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (rrw *responseReadWriter) bodyString() string {
+	return truncateBody(rrw.buf.Bytes(), rrw.maxBody)
+}
+
+// truncateBody caps body at maxBody bytes, appending a marker when it was cut short.
+func truncateBody(body []byte, maxBody int) string {
+	if len(body) <= maxBody {
+		return string(body)
+	}
+	return string(body[:maxBody]) + "...truncated"
+}
+
+// newAccessLogWriter builds the lumberjack-backed rotating writer for the access log,
+// or nil if logging is disabled (no ASCIIDOCTOR_HTTP_LOG_PATH configured).
+func newAccessLogWriter(config *Config) io.Writer {
+	if config.HTTPLogPath == "" {
+		return nil
+	}
+
+	return &lumberjack.Logger{
+		Filename:   config.HTTPLogPath,
+		MaxSize:    config.HTTPLogMaxSizeMB,
+		MaxBackups: config.HTTPLogMaxBackups,
+		MaxAge:     config.HTTPLogMaxAgeDays,
+		Compress:   config.HTTPLogGzip,
+	}
+}
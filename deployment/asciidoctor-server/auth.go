@@ -0,0 +1,300 @@
+package main
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type contextKey string
+
+const principalContextKey contextKey = "principal"
+
+// AuthMode selects how authMiddleware validates incoming requests. It's derived from
+// Config, not set directly by operators: "jwt" if either JWT env var is set, else
+// "bearer" if ASCIIDOCTOR_API_TOKENS is set, else "none".
+type AuthMode string
+
+const (
+	AuthModeNone   AuthMode = "none"
+	AuthModeBearer AuthMode = "bearer"
+	AuthModeJWT    AuthMode = "jwt"
+)
+
+// Principal is the authenticated caller stashed into the request context on success -
+// handleConvert logs it next to request_id in its completion event so audit trails
+// tie a conversion back to who requested it.
+type Principal struct {
+	Subject string
+	Claims  jwt.MapClaims
+}
+
+func principalFromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalContextKey).(*Principal)
+	return principal
+}
+
+// authExemptPaths don't require authentication even when auth is enabled - health
+// checks and API docs need to work for load balancers and unauthenticated callers
+// probing what the service offers.
+var authExemptPaths = map[string]bool{
+	"/":        true,
+	"/healthz": true,
+	"/api":     true,
+}
+
+// authMiddleware is a no-op unless s.authMode is bearer or jwt (see AuthMode). It's
+// registered last in setupRoutes so corsMiddleware's OPTIONS short-circuit and
+// recoveryMiddleware's panic safety net both still wrap it.
+func (s *Server) authMiddleware(next http.Handler) http.Handler {
+	if s.authMode == AuthModeNone {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if authExemptPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		requestID := "unknown"
+		if id := r.Context().Value("request_id"); id != nil {
+			requestID = id.(string)
+		}
+
+		token := bearerToken(r)
+		if token == "" {
+			s.writeError(w, http.StatusUnauthorized, "Unauthorized", "Missing or malformed Authorization: Bearer <token> header", requestID)
+			return
+		}
+
+		var principal *Principal
+		var err error
+		switch s.authMode {
+		case AuthModeBearer:
+			principal, err = s.authenticateBearer(token)
+		case AuthModeJWT:
+			principal, err = s.authenticateJWT(r.Context(), token)
+		}
+		if err != nil {
+			s.logger.Warn("auth", "Rejected request with invalid credentials", map[string]interface{}{
+				"request_id": requestID,
+				"path":       r.URL.Path,
+				"error":      err.Error(),
+			})
+			s.writeError(w, http.StatusUnauthorized, "Unauthorized", err.Error(), requestID)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), principalContextKey, principal)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func bearerToken(r *http.Request) string {
+	const prefix = "Bearer "
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, prefix) {
+		return ""
+	}
+	return strings.TrimSpace(header[len(prefix):])
+}
+
+// authenticateBearer checks token against s.apiTokens in constant time, so a caller
+// probing for a valid token can't learn anything from response timing. The Subject
+// stashed on success is a fingerprint, not the token itself, so it's safe to log.
+func (s *Server) authenticateBearer(token string) (*Principal, error) {
+	tokenBytes := []byte(token)
+	for _, candidate := range s.apiTokens {
+		if subtle.ConstantTimeCompare(tokenBytes, []byte(candidate)) == 1 {
+			return &Principal{Subject: "token:" + tokenFingerprint(token)}, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid API token")
+}
+
+func tokenFingerprint(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])[:8]
+}
+
+// authenticateJWT verifies token's signature (via s.jwks for RS256 or s.jwtHMACSecret
+// for HS256) and its issuer/audience claims, returning the token's subject and claims
+// as the request's Principal.
+func (s *Server) authenticateJWT(ctx context.Context, tokenString string) (*Principal, error) {
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		if s.jwks != nil {
+			if _, ok := t.Method.(*jwt.SigningMethodRSA); !ok {
+				return nil, fmt.Errorf("unexpected signing method %v", t.Header["alg"])
+			}
+			kid, _ := t.Header["kid"].(string)
+			if kid == "" {
+				return nil, fmt.Errorf("token is missing a kid header")
+			}
+			return s.jwks.keyFor(ctx, kid)
+		}
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", t.Header["alg"])
+		}
+		return s.jwtHMACSecret, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+	if !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	if s.jwtIssuer != "" {
+		issuer, _ := claims.GetIssuer()
+		if issuer != s.jwtIssuer {
+			return nil, fmt.Errorf("unexpected issuer %q", issuer)
+		}
+	}
+	if s.jwtAudience != "" {
+		audience, _ := claims.GetAudience()
+		found := false
+		for _, aud := range audience {
+			if aud == s.jwtAudience {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("token audience does not include %q", s.jwtAudience)
+		}
+	}
+
+	subject, _ := claims.GetSubject()
+	return &Principal{Subject: subject, Claims: claims}, nil
+}
+
+// jwkKey is one entry of a JWKS document's "keys" array - only the RSA fields
+// (kty=RSA) are supported, which covers every major JWKS provider (Auth0, Cognito,
+// Keycloak, etc.) issuing RS256 tokens.
+type jwkKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type jwkSet struct {
+	Keys []jwkKey `json:"keys"`
+}
+
+func (k jwkKey) rsaPublicKey() (*rsa.PublicKey, error) {
+	nBytes, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("decoding modulus: %w", err)
+	}
+	eBytes, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("decoding exponent: %w", err)
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(nBytes),
+		E: int(new(big.Int).SetBytes(eBytes).Int64()),
+	}, nil
+}
+
+// jwksCache fetches and caches a JWKS document, refreshing it once refreshTTL has
+// elapsed since the last successful fetch. An unknown kid triggers an immediate
+// refresh (the signer may have rotated keys since the last fetch) rather than waiting
+// out the TTL.
+type jwksCache struct {
+	url        string
+	refreshTTL time.Duration
+	httpClient *http.Client
+
+	mu        sync.RWMutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+func newJWKSCache(url string, refreshTTL time.Duration) *jwksCache {
+	return &jwksCache{
+		url:        url,
+		refreshTTL: refreshTTL,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		keys:       make(map[string]*rsa.PublicKey),
+	}
+}
+
+func (c *jwksCache) keyFor(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	c.mu.RLock()
+	key, ok := c.keys[kid]
+	stale := time.Since(c.fetchedAt) > c.refreshTTL
+	c.mu.RUnlock()
+	if ok && !stale {
+		return key, nil
+	}
+
+	if err := c.refresh(ctx); err != nil {
+		if ok {
+			// Serve the stale key rather than fail every request over a transient
+			// JWKS endpoint outage.
+			return key, nil
+		}
+		return nil, fmt.Errorf("fetching JWKS: %w", err)
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	key, ok = c.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key, nil
+}
+
+func (c *jwksCache) refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("JWKS endpoint returned status %d", resp.StatusCode)
+	}
+
+	var set jwkSet
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("decoding JWKS response: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		pub, err := k.rsaPublicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+
+	c.mu.Lock()
+	c.keys = keys
+	c.fetchedAt = time.Now()
+	c.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/client"
+)
+
+// Backend runs an asciidoctor CLI invocation somewhere - locally on the host, or
+// sandboxed inside a container - and returns its combined stdout/stderr.
+// This exists because AsciiDoc supports include::[] and we enable allow-uri-read
+// unconditionally, so untrusted content can otherwise read the host filesystem
+// and reach arbitrary URLs from the same machine running the server.
+type Backend interface {
+	// Convert runs `asciidoctor args...` with workDir as the working directory
+	// (and, for sandboxed backends, as the only directory visible to the process).
+	Convert(ctx context.Context, workDir string, args []string) ([]byte, error)
+	// Verify checks that the backend is able to run conversions at all.
+	Verify(ctx context.Context) error
+}
+
+// LocalBackend runs asciidoctor directly on the host - the behavior this server has
+// always had. asciidoctorPath may be "bundle", in which case args are prefixed with
+// "exec asciidoctor" and the bundle environment is applied, mirroring findAsciidoctor.
+type LocalBackend struct {
+	asciidoctorPath string
+	cfg             Config
+}
+
+func NewLocalBackend(asciidoctorPath string, cfg Config) *LocalBackend {
+	return &LocalBackend{asciidoctorPath: asciidoctorPath, cfg: cfg}
+}
+
+func (b *LocalBackend) Convert(ctx context.Context, workDir string, args []string) ([]byte, error) {
+	cmd := buildAsciidoctorCmd(ctx, b.asciidoctorPath, b.cfg, args)
+	cmd.Dir = workDir
+	return cmd.CombinedOutput()
+}
+
+func (b *LocalBackend) Verify(ctx context.Context) error {
+	cmd := buildAsciidoctorCmd(ctx, b.asciidoctorPath, b.cfg, []string{"--version"})
+	return cmd.Run()
+}
+
+// DockerBackend runs each conversion inside a short-lived container built from a
+// preconfigured image (e.g. asciidoctor/docker-asciidoctor), bind-mounting workDir
+// as the container's only writable path and enforcing CPU/memory limits from Config.
+// This isolates untrusted AsciiDoc (include::, allow-uri-read) from the host.
+type DockerBackend struct {
+	cli    *client.Client
+	image  string
+	cpus   float64
+	memMB  int64
+	logger *Logger
+}
+
+func NewDockerBackend(logger *Logger, cfg Config) (*DockerBackend, error) {
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create docker client: %w", err)
+	}
+
+	return &DockerBackend{
+		cli:    cli,
+		image:  cfg.DockerImage,
+		cpus:   cfg.DockerCPULimit,
+		memMB:  cfg.DockerMemoryLimitMB,
+		logger: logger,
+	}, nil
+}
+
+func (b *DockerBackend) Verify(ctx context.Context) error {
+	_, err := b.cli.Ping(ctx)
+	if err != nil {
+		return fmt.Errorf("docker daemon unreachable: %w", err)
+	}
+	return nil
+}
+
+// Convert creates a throwaway container per job, bind-mounts workDir at /documents
+// (the docker-asciidoctor image's default working directory), runs the given
+// asciidoctor args, streams container logs into the server log, and removes the
+// container regardless of outcome.
+func (b *DockerBackend) Convert(ctx context.Context, workDir string, args []string) ([]byte, error) {
+	const containerWorkdir = "/documents"
+
+	resp, err := b.cli.ContainerCreate(ctx,
+		&container.Config{
+			Image:      b.image,
+			Cmd:        append([]string{"asciidoctor"}, args...),
+			WorkingDir: containerWorkdir,
+			Tty:        false,
+		},
+		&container.HostConfig{
+			Mounts: []mount.Mount{
+				{
+					Type:   mount.TypeBind,
+					Source: workDir,
+					Target: containerWorkdir,
+				},
+			},
+			Resources: container.Resources{
+				NanoCPUs: int64(b.cpus * 1e9),
+				Memory:   b.memMB * 1024 * 1024,
+			},
+			NetworkMode: "none", // no allow-uri-read exfiltration path from inside the sandbox
+			AutoRemove:  false,  // remove explicitly below so we can still fetch logs on failure
+		},
+		nil, nil, "",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create conversion container: %w", err)
+	}
+	defer b.cli.ContainerRemove(context.Background(), resp.ID, container.RemoveOptions{Force: true})
+
+	if err := b.cli.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		return nil, fmt.Errorf("failed to start conversion container: %w", err)
+	}
+
+	statusCh, errCh := b.cli.ContainerWait(ctx, resp.ID, container.WaitConditionNotRunning)
+	select {
+	case err := <-errCh:
+		if err != nil {
+			return nil, fmt.Errorf("error waiting for conversion container: %w", err)
+		}
+	case status := <-statusCh:
+		logs, logErr := b.cli.ContainerLogs(ctx, resp.ID, container.LogsOptions{ShowStdout: true, ShowStderr: true})
+		var output []byte
+		if logErr == nil {
+			defer logs.Close()
+			output, _ = io.ReadAll(logs)
+		}
+		if status.StatusCode != 0 {
+			return output, fmt.Errorf("conversion container exited with status %d", status.StatusCode)
+		}
+		return output, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	return nil, fmt.Errorf("conversion container ended without a status")
+}
+
+// resolveBackend picks the configured conversion backend, falling back to LocalBackend
+// (today's behavior) if the Docker daemon is unreachable, or the embedded wasm runtime
+// fails to load, so operators without Docker/a built wasm artifact aren't forced into it.
+func resolveBackend(logger *Logger, cfg Config, asciidoctorPath string) Backend {
+	switch cfg.ConverterBackend {
+	case "docker":
+		return resolveDockerBackend(logger, cfg, asciidoctorPath)
+	case "embedded":
+		return resolveEmbeddedBackend(logger, cfg, asciidoctorPath)
+	default:
+		return NewLocalBackend(asciidoctorPath, cfg)
+	}
+}
+
+func resolveDockerBackend(logger *Logger, cfg Config, asciidoctorPath string) Backend {
+	docker, err := NewDockerBackend(logger, cfg)
+	if err != nil {
+		logger.Warn("converter", "Docker backend unavailable, falling back to local exec", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return NewLocalBackend(asciidoctorPath, cfg)
+	}
+
+	verifyCtx, cancel := context.WithTimeout(context.Background(), VerifyTimeout)
+	defer cancel()
+	if err := docker.Verify(verifyCtx); err != nil {
+		logger.Warn("converter", "Docker backend failed verification, falling back to local exec", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return NewLocalBackend(asciidoctorPath, cfg)
+	}
+
+	logger.Info("converter", "Using Docker conversion backend", map[string]interface{}{
+		"image": cfg.DockerImage,
+		"cpus":  cfg.DockerCPULimit,
+		"mem_mb": cfg.DockerMemoryLimitMB,
+	})
+	return docker
+}
+
+// resolveEmbeddedBackend loads the embedded WASI asciidoctor runtime for
+// ConverterBackend: "embedded", falling back to local exec if the wasm module fails to
+// load or verify (e.g. wasm/asciidoctor.wasm is still the build placeholder - see
+// wasm/README.md).
+func resolveEmbeddedBackend(logger *Logger, cfg Config, asciidoctorPath string) Backend {
+	embedded, err := NewEmbeddedBackend(logger)
+	if err != nil {
+		logger.Warn("converter", "Embedded asciidoctor runtime unavailable, falling back to local exec", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return NewLocalBackend(asciidoctorPath, cfg)
+	}
+
+	verifyCtx, cancel := context.WithTimeout(context.Background(), VerifyTimeout)
+	defer cancel()
+	if err := embedded.Verify(verifyCtx); err != nil {
+		logger.Warn("converter", "Embedded asciidoctor runtime failed verification, falling back to local exec", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return NewLocalBackend(asciidoctorPath, cfg)
+	}
+
+	logger.Info("converter", "Using embedded WASI conversion backend", nil)
+	return embedded
+}
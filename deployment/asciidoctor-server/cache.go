@@ -0,0 +1,387 @@
+package main
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ConversionCache stores finished artifacts keyed by a SHA-256 over the conversion
+// inputs that actually affect the output (content plus every attribute asciidoctor
+// receives), so re-converting the same AsciiDoc with the same metadata - common for a
+// Nostr article event re-rendered many times across relays/clients - is a cache
+// lookup instead of a fresh asciidoctor/ebook-convert invocation.
+//
+// Eviction is LRU by access order, bounded by maxSizeBytes, with an optional TTL on
+// top. The LRU index lives in memory only - it's rebuilt empty on restart, so a
+// restart forgets access order/size accounting for anything already on disk. TTL
+// expiry still holds for entries the index has forgotten: Lookup falls back to the
+// sidecar's own StoredAt for anything found on disk but untracked. That matches this
+// cache's tolerance for best-effort bookkeeping elsewhere (see fs.go's
+// S3Fs.RemoveAll) rather than adding a Fs.List/Walk method solely to rehydrate it -
+// it just can't extend to dropping correctness guarantees along with it.
+type ConversionCache struct {
+	fs     Fs
+	logger *Logger
+	prefix string
+
+	maxSizeBytes int64
+	ttl          time.Duration
+
+	mu        sync.Mutex
+	order     *list.List // front = most recently used
+	index     map[string]*list.Element
+	totalSize int64
+
+	hits   int64
+	misses int64
+}
+
+type cacheEntry struct {
+	path     string
+	size     int64
+	storedAt time.Time
+}
+
+// cacheSidecar is the JSON metadata written alongside each cached artifact.
+type cacheSidecar struct {
+	MimeType string    `json:"mime_type"`
+	Size     int64     `json:"size"`
+	StoredAt time.Time `json:"stored_at"`
+}
+
+// NewConversionCache builds a cache that stores entries under fs, the same storage
+// backend Converter uses for finished artifacts (local disk, memory, or S3).
+// maxSizeBytes <= 0 disables size-based eviction; ttl <= 0 disables expiry.
+func NewConversionCache(fs Fs, logger *Logger, maxSizeBytes int64, ttl time.Duration) *ConversionCache {
+	return &ConversionCache{
+		fs:           fs,
+		logger:       logger,
+		prefix:       "cache-",
+		maxSizeBytes: maxSizeBytes,
+		ttl:          ttl,
+		order:        list.New(),
+		index:        make(map[string]*list.Element),
+	}
+}
+
+// conversionCacheKey hashes every input that influences the rendered output: the raw
+// content plus title/authors/pubkey/version/description/summary/dates/image/theme,
+// the target backend, and the asciidoctor version (a gem upgrade can change output).
+func conversionCacheKey(req *ConvertRequest, backend, asciidoctorVersion string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "asciidoctor=%s\nbackend=%s\ntitle=%s\nauthor=%s\nauthors=%s\npubkey=%s\n",
+		asciidoctorVersion, backend, req.Title, req.Author, strings.Join(req.Authors, ","), req.Pubkey)
+	fmt.Fprintf(h, "version=%s\ndescription=%s\nsummary=%s\npublished_on=%s\ncreated_at=%s\nimage=%s\ntheme=%s\n",
+		req.Version, req.Description, req.Summary, req.PublishedOn, req.CreatedAt, req.Image, req.Theme)
+	if opts := req.ImageOptions; opts != nil {
+		// Included so two requests for identical content but different image
+		// transcoding (format defaults or ?image_max_width=/?image_grayscale=/
+		// ?image_jpeg_quality= overrides) never collide on the same cache entry.
+		fmt.Fprintf(h, "image_opts=%dx%d,q=%d,gray=%t\n", opts.MaxWidth, opts.MaxHeight, opts.JPEGQuality, opts.Grayscale)
+	}
+	fmt.Fprintf(h, "---\n%s", req.Content)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (cc *ConversionCache) path(key, extension string) string {
+	return fmt.Sprintf("%s%s.%s", cc.prefix, key, extension)
+}
+
+func (cc *ConversionCache) sidecarPath(path string) string {
+	return path + ".json"
+}
+
+// expiredOnDisk reads path's sidecar and reports whether its StoredAt is older than
+// cc.ttl. A missing or unreadable sidecar is treated as expired - a cached artifact
+// this cache can't confirm the age of is no safer to serve than a stale one.
+func (cc *ConversionCache) expiredOnDisk(path string) bool {
+	data, err := cc.fs.ReadFile(cc.sidecarPath(path))
+	if err != nil {
+		return true
+	}
+	var sidecar cacheSidecar
+	if err := json.Unmarshal(data, &sidecar); err != nil {
+		return true
+	}
+	return time.Since(sidecar.StoredAt) > cc.ttl
+}
+
+// Lookup returns a ConvertResult backed by the cached artifact, if present and not
+// expired. A hit moves the entry to the front of the LRU order and is counted toward
+// Stats, same as a miss (expired-and-evicted or never-stored) counts toward it.
+func (cc *ConversionCache) Lookup(key, extension string) (*ConvertResult, bool) {
+	path := cc.path(key, extension)
+
+	cc.mu.Lock()
+	elem, tracked := cc.index[path]
+	if tracked {
+		entry := elem.Value.(*cacheEntry)
+		if cc.ttl > 0 && time.Since(entry.storedAt) > cc.ttl {
+			cc.removeElementLocked(elem)
+			cc.mu.Unlock()
+			cc.fs.Remove(path)
+			cc.fs.Remove(cc.sidecarPath(path))
+			atomic.AddInt64(&cc.misses, 1)
+			return nil, false
+		}
+		cc.order.MoveToFront(elem)
+	}
+	cc.mu.Unlock()
+
+	size, err := cc.fs.Stat(path)
+	if err != nil {
+		atomic.AddInt64(&cc.misses, 1)
+		return nil, false
+	}
+
+	// Untracked but present on disk: the in-memory index was empty (a restart) when
+	// this path was first looked up, so TTL was never checked above. Fall back to the
+	// sidecar's own StoredAt rather than serving a possibly-expired artifact forever.
+	if !tracked && cc.ttl > 0 {
+		if expired := cc.expiredOnDisk(path); expired {
+			cc.fs.Remove(path)
+			cc.fs.Remove(cc.sidecarPath(path))
+			atomic.AddInt64(&cc.misses, 1)
+			return nil, false
+		}
+	}
+
+	atomic.AddInt64(&cc.hits, 1)
+	return &ConvertResult{
+		FilePath:   path,
+		Size:       size,
+		MimeType:   getMimeType(extension),
+		fs:         cc.fs,
+		storageKey: path,
+	}, true
+}
+
+// Stats returns hit/miss counters and current size/entry count for handleHealth.
+func (cc *ConversionCache) Stats() map[string]interface{} {
+	cc.mu.Lock()
+	bytes := cc.totalSize
+	entries := len(cc.index)
+	cc.mu.Unlock()
+
+	return map[string]interface{}{
+		"cache_hits":    atomic.LoadInt64(&cc.hits),
+		"cache_misses":  atomic.LoadInt64(&cc.misses),
+		"cache_bytes":   bytes,
+		"cache_entries": entries,
+	}
+}
+
+// Invalidate removes the cache entry for key/extension, if present, returning whether
+// anything was actually evicted - used by Converter.InvalidateCache for admin
+// endpoints that need to force a specific document to re-convert (e.g. after an
+// asciidoctor template change that the asciidoctor-version component of the cache key
+// wouldn't otherwise catch).
+func (cc *ConversionCache) Invalidate(key, extension string) bool {
+	path := cc.path(key, extension)
+
+	cc.mu.Lock()
+	elem, tracked := cc.index[path]
+	if tracked {
+		cc.removeElementLocked(elem)
+	}
+	cc.mu.Unlock()
+
+	if !tracked {
+		return false
+	}
+
+	cc.fs.Remove(path)
+	cc.fs.Remove(cc.sidecarPath(path))
+	return true
+}
+
+// Store copies result's bytes into the cache under key, writes a JSON sidecar with
+// its metadata, and evicts the least-recently-used entries if the cache now exceeds
+// maxSizeBytes.
+func (cc *ConversionCache) Store(key, extension string, result *ConvertResult) error {
+	rc, err := result.Reader()
+	if err != nil {
+		return fmt.Errorf("failed to open conversion result for caching: %w", err)
+	}
+	defer rc.Close()
+
+	data := make([]byte, 0, result.Size)
+	buf := make([]byte, 32*1024)
+	for {
+		n, readErr := rc.Read(buf)
+		if n > 0 {
+			data = append(data, buf[:n]...)
+		}
+		if readErr != nil {
+			break
+		}
+	}
+
+	path := cc.path(key, extension)
+	if err := cc.fs.WriteFile(path, data, FileModeFile); err != nil {
+		return err
+	}
+
+	storedAt := time.Now()
+	sidecar := cacheSidecar{MimeType: result.MimeType, Size: int64(len(data)), StoredAt: storedAt}
+	if sidecarData, err := json.Marshal(sidecar); err == nil {
+		if err := cc.fs.WriteFile(cc.sidecarPath(path), sidecarData, FileModeFile); err != nil {
+			cc.logger.Warn("converter", "Failed to write cache sidecar metadata", map[string]interface{}{
+				"path":  path,
+				"error": err.Error(),
+			})
+		}
+	}
+
+	cc.mu.Lock()
+	if elem, exists := cc.index[path]; exists {
+		cc.totalSize -= elem.Value.(*cacheEntry).size
+		cc.order.MoveToFront(elem)
+		elem.Value.(*cacheEntry).size = int64(len(data))
+		elem.Value.(*cacheEntry).storedAt = storedAt
+	} else {
+		elem := cc.order.PushFront(&cacheEntry{path: path, size: int64(len(data)), storedAt: storedAt})
+		cc.index[path] = elem
+	}
+	cc.totalSize += int64(len(data))
+	evicted := cc.evictLocked()
+	cc.mu.Unlock()
+
+	for _, evictedPath := range evicted {
+		cc.fs.Remove(evictedPath)
+		cc.fs.Remove(cc.sidecarPath(evictedPath))
+	}
+	if len(evicted) > 0 {
+		cc.logger.Info("converter", "Evicted least-recently-used cache entries", map[string]interface{}{
+			"evicted_count": len(evicted),
+		})
+	}
+
+	return nil
+}
+
+// evictLocked removes least-recently-used entries until totalSize is within
+// maxSizeBytes. Caller must hold cc.mu.
+func (cc *ConversionCache) evictLocked() []string {
+	if cc.maxSizeBytes <= 0 {
+		return nil
+	}
+
+	var evicted []string
+	for cc.totalSize > cc.maxSizeBytes {
+		back := cc.order.Back()
+		if back == nil {
+			break
+		}
+		entry := back.Value.(*cacheEntry)
+		cc.order.Remove(back)
+		delete(cc.index, entry.path)
+		cc.totalSize -= entry.size
+		evicted = append(evicted, entry.path)
+	}
+	return evicted
+}
+
+func (cc *ConversionCache) removeElementLocked(elem *list.Element) {
+	entry := elem.Value.(*cacheEntry)
+	cc.order.Remove(elem)
+	delete(cc.index, entry.path)
+	cc.totalSize -= entry.size
+}
+
+// Purge removes every tracked cache entry and its sidecar from storage.
+func (cc *ConversionCache) Purge() {
+	cc.mu.Lock()
+	paths := make([]string, 0, len(cc.index))
+	for path := range cc.index {
+		paths = append(paths, path)
+	}
+	cc.order = list.New()
+	cc.index = make(map[string]*list.Element)
+	cc.totalSize = 0
+	cc.mu.Unlock()
+
+	for _, path := range paths {
+		cc.fs.Remove(path)
+		cc.fs.Remove(cc.sidecarPath(path))
+	}
+
+	cc.logger.Info("converter", "Conversion cache purged", map[string]interface{}{
+		"entries_removed": len(paths),
+	})
+}
+
+// PurgeCache clears the entire conversion cache. A no-op when caching is disabled.
+func (c *Converter) PurgeCache() {
+	if c.cache == nil {
+		return
+	}
+	c.cache.Purge()
+}
+
+// cacheExtensions lists every extension the converter's withCache call sites store
+// under (see convert, convertHTML5, ConvertToDocBook5, ConvertToEPUBNative) - a
+// conversionCacheKey hash doesn't carry the extension, since that's appended by
+// ConversionCache.path separately, so invalidating by key alone means trying each one.
+var cacheExtensions = []string{"epub", "html", "pdf", "xml"}
+
+// InvalidateCache evicts the cached artifact for key - a SHA-256 conversionCacheKey
+// for some content+backend+asciidoctor-version combination - across every extension
+// the cache is known to store under. Intended for admin endpoints that need to force
+// a specific document to re-convert without waiting out the cache TTL. Returns
+// whether anything was actually evicted; a no-op (false) when caching is disabled.
+func (c *Converter) InvalidateCache(key string) bool {
+	if c.cache == nil {
+		return false
+	}
+
+	evicted := false
+	for _, ext := range cacheExtensions {
+		if c.cache.Invalidate(key, ext) {
+			evicted = true
+		}
+	}
+	return evicted
+}
+
+// withCache checks the cache before running produce, and stores a successful result
+// for next time. A nil c.cache (caching disabled) always runs produce directly.
+func (c *Converter) withCache(req *ConvertRequest, backend, extension string, produce func() (*ConvertResult, error)) (*ConvertResult, error) {
+	if c.cache == nil {
+		return produce()
+	}
+
+	key := conversionCacheKey(req, backend, c.asciidoctorVersion)
+	if cached, ok := c.cache.Lookup(key, extension); ok {
+		c.logger.Info("converter", "Conversion cache hit", map[string]interface{}{
+			"backend":   backend,
+			"cache_key": key,
+		})
+		return cached, nil
+	}
+	c.logger.Info("converter", "Conversion cache miss", map[string]interface{}{
+		"backend":   backend,
+		"cache_key": key,
+	})
+
+	result, err := produce()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.cache.Store(key, extension, result); err != nil {
+		c.logger.Warn("converter", "Failed to store conversion result in cache", map[string]interface{}{
+			"backend": backend,
+			"error":   err.Error(),
+		})
+	}
+
+	return result, nil
+}
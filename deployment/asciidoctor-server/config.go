@@ -1,7 +1,11 @@
 package main
 
 import (
+	"compress/gzip"
+	"io"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -23,6 +27,119 @@ type Config struct {
 	// Temporary directory configuration
 	TempDir string
 
+	// Worker pool configuration - persistent Asciidoctor processes to avoid
+	// paying Ruby/Bundler/Asciidoctor startup cost on every conversion.
+	// 0 disables the pool and falls back to one-shot exec per request.
+	WorkerPoolSize int
+
+	// Conversion backend ("local", "docker", or "embedded") - see Backend in backend.go
+	ConverterBackend    string
+	DockerImage         string
+	DockerCPULimit      float64
+	DockerMemoryLimitMB int64
+
+	// Artifact storage backend ("os", "mem", or "s3") - see Fs in fs.go
+	StorageBackend string
+	S3Bucket       string
+	S3Prefix       string
+
+	// Conversion cache - skips re-running asciidoctor when content/attributes/backend
+	// are identical to a previous request. Stored through the same Fs as artifacts.
+	ConversionCacheEnabled bool
+	// CacheMaxSizeMB bounds total cache size; the least-recently-used entries are
+	// evicted once it's exceeded. <= 0 disables size-based eviction.
+	CacheMaxSizeMB int64
+	// CacheTTL expires a cache entry this long after it was stored, even if it's
+	// still within the size bound. <= 0 disables expiry.
+	CacheTTL time.Duration
+
+	// Image cache (see ImageCache in image_cache.go) - a content-addressed store for
+	// remote images referenced from AsciiDoc content, shared across every conversion
+	// so the same image isn't re-downloaded per request/per document. Stored through
+	// the same Fs as artifacts and the conversion cache.
+	ImageCacheEnabled   bool
+	ImageCacheMaxSizeMB int64
+
+	// Image transcoding (see image_transcode.go) - resizes/re-encodes downloaded
+	// images before they're embedded, so a photo-heavy article doesn't bloat the
+	// output for e-reader targets. Per-format defaults in formatImageLimits take
+	// precedence over these when they're stricter; a request can override either
+	// via ?image_max_width=/?image_max_height=/?image_jpeg_quality=/?image_grayscale=.
+	ImageMaxWidth    int
+	ImageMaxHeight   int
+	ImageJpegQuality int
+	// ImageGrayscale desaturates images for e-ink displays, which can't render color
+	// anyway - trading a smaller JPEG for information the device would discard.
+	ImageGrayscale bool
+
+	// EPUB backend ("ruby" uses asciidoctor-epub3, "native" generates EPUB 3 directly
+	// in Go via go-epub) - see ConvertToEPUBNative in epub_native.go.
+	EPUBBackend string
+
+	// Book metadata enrichment (see MetadataEnricher in metadata_enrich.go) - looks
+	// up title/authors/publisher/description/cover art for an :isbn: or
+	// :google-books-id: attribute and fills in whatever the header doesn't already
+	// set. Only runs when a request opts in via ConvertRequest.EnrichMetadata.
+	MetadataProvider  string
+	GoogleBooksAPIKey string
+	MetadataCacheTTL  time.Duration
+
+	// NativeKindle, when true, generates MOBI/AZW3 with the pure-Go PalmDoc/MOBI
+	// writer in kindle_native.go instead of shelling out to Calibre's ebook-convert.
+	NativeKindle bool
+	// KindleFontPath is an optional bundled TTF embedded via @font-face in the
+	// generated MOBI/AZW3 so typography matches the Calibre/asciidoctor-epub3 output.
+	KindleFontPath string
+
+	// Async job queue (see JobQueue in jobs.go) - lets large conversions be submitted
+	// via POST /jobs/{format} and polled instead of holding one HTTP connection open
+	// for the whole ASCIIDOCTOR_CONVERSION_TIMEOUT window.
+	JobWorkers  int
+	JobQueueSize int
+	JobTTL      time.Duration
+
+	// gzip response compression (see compressionMiddleware in middleware.go)
+	GzipLevel int
+	// GzipMinBytes skips compression for responses smaller than this, since gzipping a
+	// handful of bytes (a small JSON error body) costs more CPU than it saves bandwidth.
+	GzipMinBytes int
+	// GzipTypes overrides the Content-Type prefixes eligible for compression.
+	GzipTypes []string
+
+	// HTTP access log (see accessLogMiddleware in accesslog.go) - opt-in full
+	// request/response body capture for /convert/* calls, disabled unless
+	// HTTPLogPath is set.
+	HTTPLogPath string
+	// HTTPLogMaxBody truncates captured bodies beyond this many bytes.
+	HTTPLogMaxBody int
+	// HTTPLogMaxSizeMB, HTTPLogMaxBackups and HTTPLogMaxAgeDays are lumberjack's
+	// rotation knobs: size that triggers rotation, backups kept, days kept.
+	HTTPLogMaxSizeMB  int
+	HTTPLogMaxBackups int
+	HTTPLogMaxAgeDays int
+	// HTTPLogGzip compresses rotated-out access log files.
+	HTTPLogGzip bool
+
+	// Retry (see RetryConfig/withRetry in retry.go) - disabled unless
+	// ASCIIDOCTOR_RETRY_ENABLED is set to "true"
+	Retry RetryConfig
+
+	// Metrics (see Metrics in metrics.go) - disabled unless ASCIIDOCTOR_METRICS_ENABLED
+	// is set to "true". MetricsBind, if set, serves /metrics on its own listener
+	// instead of alongside the main routes.
+	MetricsEnabled bool
+	MetricsBind    string
+
+	// Auth (see authMiddleware in auth.go) - AuthMode is derived, not set directly:
+	// "jwt" if either JWT env var below is set, else "bearer" if APITokens is
+	// non-empty, else "none" and authMiddleware is a no-op.
+	APITokens      []string
+	JWTJWKSURL     string
+	JWTHMACSecret  []byte
+	JWTIssuer      string
+	JWTAudience    string
+	JWTJWKSRefresh time.Duration
+
 	// Debug mode
 	Debug bool
 }
@@ -50,6 +167,187 @@ func LoadConfig() (cfg Config) {
 	// Temporary directory
 	cfg.TempDir = getEnvOrDefault("TMPDIR", DefaultTempDir)
 
+	// Worker pool size
+	poolSizeStr := getEnvOrDefault("ASCIIDOCTOR_WORKER_POOL_SIZE", strconv.Itoa(DefaultWorkerPoolSize))
+	if parsed, err := strconv.Atoi(poolSizeStr); err == nil && parsed >= 0 {
+		cfg.WorkerPoolSize = parsed
+	} else {
+		cfg.WorkerPoolSize = DefaultWorkerPoolSize
+	}
+
+	// Conversion backend
+	cfg.ConverterBackend = getEnvOrDefault("ASCIIDOCTOR_CONVERTER_BACKEND", DefaultConverterBackend)
+	cfg.DockerImage = getEnvOrDefault("ASCIIDOCTOR_DOCKER_IMAGE", DefaultDockerImage)
+	if parsed, err := strconv.ParseFloat(getEnvOrDefault("ASCIIDOCTOR_DOCKER_CPU_LIMIT", "1.0"), 64); err == nil {
+		cfg.DockerCPULimit = parsed
+	} else {
+		cfg.DockerCPULimit = 1.0
+	}
+	if parsed, err := strconv.ParseInt(getEnvOrDefault("ASCIIDOCTOR_DOCKER_MEMORY_LIMIT_MB", "512"), 10, 64); err == nil {
+		cfg.DockerMemoryLimitMB = parsed
+	} else {
+		cfg.DockerMemoryLimitMB = 512
+	}
+
+	// Artifact storage backend
+	cfg.StorageBackend = getEnvOrDefault("ASCIIDOCTOR_STORAGE_BACKEND", DefaultStorageBackend)
+	cfg.S3Bucket = getEnvOrDefault("ASCIIDOCTOR_S3_BUCKET", "")
+	cfg.S3Prefix = getEnvOrDefault("ASCIIDOCTOR_S3_PREFIX", "")
+
+	// Conversion cache
+	cfg.ConversionCacheEnabled = getEnvOrDefault("ASCIIDOCTOR_CONVERSION_CACHE_ENABLED", strconv.FormatBool(DefaultConversionCacheEnabled)) == "true"
+	if parsed, err := strconv.ParseInt(getEnvOrDefault("ASCIIDOCTOR_CACHE_MAX_SIZE_MB", strconv.FormatInt(DefaultCacheMaxSizeMB, 10)), 10, 64); err == nil {
+		cfg.CacheMaxSizeMB = parsed
+	} else {
+		cfg.CacheMaxSizeMB = DefaultCacheMaxSizeMB
+	}
+	if parsed, err := time.ParseDuration(getEnvOrDefault("ASCIIDOCTOR_CACHE_TTL", DefaultCacheTTL.String())); err == nil {
+		cfg.CacheTTL = parsed
+	} else {
+		cfg.CacheTTL = DefaultCacheTTL
+	}
+
+	// Image cache
+	cfg.ImageCacheEnabled = getEnvOrDefault("ASCIIDOCTOR_IMAGE_CACHE_ENABLED", strconv.FormatBool(DefaultImageCacheEnabled)) == "true"
+	if parsed, err := strconv.ParseInt(getEnvOrDefault("ASCIIDOCTOR_IMAGE_CACHE_MAX_SIZE_MB", strconv.FormatInt(DefaultImageCacheMaxSizeMB, 10)), 10, 64); err == nil {
+		cfg.ImageCacheMaxSizeMB = parsed
+	} else {
+		cfg.ImageCacheMaxSizeMB = DefaultImageCacheMaxSizeMB
+	}
+
+	// Image transcoding
+	if parsed, err := strconv.Atoi(getEnvOrDefault("ASCIIDOCTOR_IMAGE_MAX_WIDTH", strconv.Itoa(DefaultImageMaxWidth))); err == nil {
+		cfg.ImageMaxWidth = parsed
+	} else {
+		cfg.ImageMaxWidth = DefaultImageMaxWidth
+	}
+	if parsed, err := strconv.Atoi(getEnvOrDefault("ASCIIDOCTOR_IMAGE_MAX_HEIGHT", strconv.Itoa(DefaultImageMaxHeight))); err == nil {
+		cfg.ImageMaxHeight = parsed
+	} else {
+		cfg.ImageMaxHeight = DefaultImageMaxHeight
+	}
+	if parsed, err := strconv.Atoi(getEnvOrDefault("ASCIIDOCTOR_IMAGE_JPEG_QUALITY", strconv.Itoa(DefaultImageJpegQuality))); err == nil {
+		cfg.ImageJpegQuality = parsed
+	} else {
+		cfg.ImageJpegQuality = DefaultImageJpegQuality
+	}
+	cfg.ImageGrayscale = getEnvOrDefault("ASCIIDOCTOR_IMAGE_GRAYSCALE", strconv.FormatBool(DefaultImageGrayscale)) == "true"
+
+	// EPUB backend
+	cfg.EPUBBackend = getEnvOrDefault("ASCIIDOCTOR_EPUB_BACKEND", DefaultEPUBBackend)
+
+	// Book metadata enrichment
+	cfg.MetadataProvider = getEnvOrDefault("ASCIIDOCTOR_METADATA_PROVIDER", DefaultMetadataProvider)
+	cfg.GoogleBooksAPIKey = getEnvOrDefault("ASCIIDOCTOR_GOOGLE_BOOKS_API_KEY", "")
+	if parsed, err := time.ParseDuration(getEnvOrDefault("ASCIIDOCTOR_METADATA_CACHE_TTL", DefaultMetadataCacheTTL.String())); err == nil {
+		cfg.MetadataCacheTTL = parsed
+	} else {
+		cfg.MetadataCacheTTL = DefaultMetadataCacheTTL
+	}
+
+	// Native Kindle (MOBI/AZW3) generation
+	cfg.NativeKindle = getEnvOrDefault("ASCIIDOCTOR_NATIVE_KINDLE", strconv.FormatBool(DefaultNativeKindle)) == "true"
+	cfg.KindleFontPath = getEnvOrDefault("ASCIIDOCTOR_KINDLE_FONT_PATH", DefaultKindleFontPath)
+
+	// Async job queue
+	if parsed, err := strconv.Atoi(getEnvOrDefault("ASCIIDOCTOR_WORKERS", strconv.Itoa(DefaultJobWorkers))); err == nil && parsed > 0 {
+		cfg.JobWorkers = parsed
+	} else {
+		cfg.JobWorkers = DefaultJobWorkers
+	}
+	if parsed, err := strconv.Atoi(getEnvOrDefault("ASCIIDOCTOR_QUEUE_SIZE", strconv.Itoa(DefaultJobQueueSize))); err == nil && parsed > 0 {
+		cfg.JobQueueSize = parsed
+	} else {
+		cfg.JobQueueSize = DefaultJobQueueSize
+	}
+	if parsed, err := time.ParseDuration(getEnvOrDefault("ASCIIDOCTOR_JOB_TTL", DefaultJobTTL.String())); err == nil {
+		cfg.JobTTL = parsed
+	} else {
+		cfg.JobTTL = DefaultJobTTL
+	}
+
+	// gzip response compression
+	if parsed, err := strconv.Atoi(getEnvOrDefault("ASCIIDOCTOR_GZIP_LEVEL", strconv.Itoa(DefaultGzipLevel))); err == nil {
+		if _, err := gzip.NewWriterLevel(io.Discard, parsed); err == nil {
+			cfg.GzipLevel = parsed
+		} else {
+			cfg.GzipLevel = DefaultGzipLevel
+		}
+	} else {
+		cfg.GzipLevel = DefaultGzipLevel
+	}
+	if parsed, err := strconv.Atoi(getEnvOrDefault("ASCIIDOCTOR_GZIP_MIN_BYTES", strconv.Itoa(DefaultGzipMinBytes))); err == nil && parsed >= 0 {
+		cfg.GzipMinBytes = parsed
+	} else {
+		cfg.GzipMinBytes = DefaultGzipMinBytes
+	}
+	cfg.GzipTypes = strings.Split(getEnvOrDefault("ASCIIDOCTOR_GZIP_TYPES", DefaultGzipTypes), ",")
+
+	// HTTP access log
+	cfg.HTTPLogPath = getEnvOrDefault("ASCIIDOCTOR_HTTP_LOG_PATH", "")
+	if parsed, err := strconv.Atoi(getEnvOrDefault("ASCIIDOCTOR_HTTP_LOG_MAX_BODY", strconv.Itoa(DefaultHTTPLogMaxBody))); err == nil && parsed >= 0 {
+		cfg.HTTPLogMaxBody = parsed
+	} else {
+		cfg.HTTPLogMaxBody = DefaultHTTPLogMaxBody
+	}
+	if parsed, err := strconv.Atoi(getEnvOrDefault("ASCIIDOCTOR_HTTP_LOG_MAX_SIZE_MB", strconv.Itoa(DefaultHTTPLogMaxSizeMB))); err == nil && parsed > 0 {
+		cfg.HTTPLogMaxSizeMB = parsed
+	} else {
+		cfg.HTTPLogMaxSizeMB = DefaultHTTPLogMaxSizeMB
+	}
+	if parsed, err := strconv.Atoi(getEnvOrDefault("ASCIIDOCTOR_HTTP_LOG_MAX_BACKUPS", strconv.Itoa(DefaultHTTPLogMaxBackups))); err == nil && parsed >= 0 {
+		cfg.HTTPLogMaxBackups = parsed
+	} else {
+		cfg.HTTPLogMaxBackups = DefaultHTTPLogMaxBackups
+	}
+	if parsed, err := strconv.Atoi(getEnvOrDefault("ASCIIDOCTOR_HTTP_LOG_MAX_AGE_DAYS", strconv.Itoa(DefaultHTTPLogMaxAgeDays))); err == nil && parsed >= 0 {
+		cfg.HTTPLogMaxAgeDays = parsed
+	} else {
+		cfg.HTTPLogMaxAgeDays = DefaultHTTPLogMaxAgeDays
+	}
+	cfg.HTTPLogGzip = getEnvOrDefault("ASCIIDOCTOR_HTTP_LOG_GZIP", strconv.FormatBool(DefaultHTTPLogGzip)) == "true"
+
+	// Retry
+	cfg.Retry.Enabled = getEnvOrDefault("ASCIIDOCTOR_RETRY_ENABLED", strconv.FormatBool(DefaultRetryEnabled)) == "true"
+	if parsed, err := time.ParseDuration(getEnvOrDefault("ASCIIDOCTOR_RETRY_INITIAL_INTERVAL", DefaultRetryInitialInterval.String())); err == nil {
+		cfg.Retry.InitialInterval = parsed
+	} else {
+		cfg.Retry.InitialInterval = DefaultRetryInitialInterval
+	}
+	if parsed, err := time.ParseDuration(getEnvOrDefault("ASCIIDOCTOR_RETRY_MAX_INTERVAL", DefaultRetryMaxInterval.String())); err == nil {
+		cfg.Retry.MaxInterval = parsed
+	} else {
+		cfg.Retry.MaxInterval = DefaultRetryMaxInterval
+	}
+	if parsed, err := time.ParseDuration(getEnvOrDefault("ASCIIDOCTOR_RETRY_MAX_ELAPSED_TIME", DefaultRetryMaxElapsedTime.String())); err == nil {
+		cfg.Retry.MaxElapsedTime = parsed
+	} else {
+		cfg.Retry.MaxElapsedTime = DefaultRetryMaxElapsedTime
+	}
+	if parsed, err := strconv.ParseFloat(getEnvOrDefault("ASCIIDOCTOR_RETRY_MULTIPLIER", strconv.FormatFloat(DefaultRetryMultiplier, 'f', -1, 64)), 64); err == nil && parsed > 1 {
+		cfg.Retry.Multiplier = parsed
+	} else {
+		cfg.Retry.Multiplier = DefaultRetryMultiplier
+	}
+
+	// Metrics
+	cfg.MetricsEnabled = os.Getenv("ASCIIDOCTOR_METRICS_ENABLED") == "true"
+	cfg.MetricsBind = getEnvOrDefault("ASCIIDOCTOR_METRICS_BIND", "")
+
+	// Auth
+	if tokens := os.Getenv("ASCIIDOCTOR_API_TOKENS"); tokens != "" {
+		cfg.APITokens = strings.Split(tokens, ",")
+	}
+	cfg.JWTJWKSURL = getEnvOrDefault("ASCIIDOCTOR_JWT_JWKS_URL", "")
+	cfg.JWTHMACSecret = []byte(getEnvOrDefault("ASCIIDOCTOR_JWT_HMAC_SECRET", ""))
+	cfg.JWTIssuer = getEnvOrDefault("ASCIIDOCTOR_JWT_ISSUER", "")
+	cfg.JWTAudience = getEnvOrDefault("ASCIIDOCTOR_JWT_AUDIENCE", "")
+	if parsed, err := time.ParseDuration(getEnvOrDefault("ASCIIDOCTOR_JWT_JWKS_REFRESH", DefaultJWTJWKSRefresh.String())); err == nil {
+		cfg.JWTJWKSRefresh = parsed
+	} else {
+		cfg.JWTJWKSRefresh = DefaultJWTJWKSRefresh
+	}
+
 	// Debug mode
 	cfg.Debug = os.Getenv("ASCIIDOCTOR_DEBUG") == "true"
 
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// FormatBackend adapts one of Converter's ConvertTo* methods to a uniform interface
+// so ConvertAll can dispatch by format name instead of switching on it by hand.
+type FormatBackend interface {
+	Name() string
+	Convert(ctx context.Context, req *ConvertRequest) (*ConvertResult, error)
+}
+
+type methodFormatBackend struct {
+	name    string
+	convert func(ctx context.Context, req *ConvertRequest) (*ConvertResult, error)
+}
+
+func (b methodFormatBackend) Name() string { return b.name }
+func (b methodFormatBackend) Convert(ctx context.Context, req *ConvertRequest) (*ConvertResult, error) {
+	return b.convert(ctx, req)
+}
+
+// formatBackends builds the registry ConvertAll dispatches through. Adding a new
+// output format (e.g. a future native DocBook backend) only requires a new entry
+// here.
+func (c *Converter) formatBackends() map[string]FormatBackend {
+	return map[string]FormatBackend{
+		"html5":    methodFormatBackend{name: "html5", convert: c.ConvertToHTML5},
+		"docbook5": methodFormatBackend{name: "docbook5", convert: c.ConvertToDocBook5},
+		"epub":     methodFormatBackend{name: "epub", convert: c.ConvertToEPUB},
+		"pdf":      methodFormatBackend{name: "pdf", convert: c.ConvertToPDF},
+		"mobi":     methodFormatBackend{name: "mobi", convert: c.ConvertToMOBI},
+		"azw3":     methodFormatBackend{name: "azw3", convert: c.ConvertToAZW3},
+	}
+}
+
+// ConvertAll runs the requested formats concurrently against the same request,
+// instead of a Wikistr publish flow calling ConvertTo* once per format in sequence.
+// It still dispatches one full ConvertTo* per format - asciidoctor's epub3, pdf and
+// html5 processors are separate Ruby pipelines that can't share a parse - but the two
+// parts of that work that ARE shareable are deduplicated up front, once, before the
+// fan-out: metadata enrichment (enrichMetadata is otherwise called redundantly, and
+// racily, by every backend) and remote image downloads (prefetchImages populates the
+// shared ImageCache so each backend's own ImageHandler resolves from disk instead of
+// the network). When cfg.ConversionCacheEnabled is also on, requesting "epub"
+// alongside "mobi"/"azw3" further collapses to a single asciidoctor-epub3 invocation,
+// since mobi/azw3 build their EPUB intermediate through the same cache key.
+func (c *Converter) ConvertAll(ctx context.Context, req *ConvertRequest, formats []string) (map[string]*ConvertResult, error) {
+	if len(formats) == 0 {
+		return nil, fmt.Errorf("no formats requested")
+	}
+
+	backends := c.formatBackends()
+	for _, format := range formats {
+		if _, ok := backends[format]; !ok {
+			return nil, fmt.Errorf("unknown format %q", format)
+		}
+	}
+
+	// Enrich once, before any format sees the request - enrichMetadata mutates
+	// req.Content in place, so doing it here rather than per-backend is both the
+	// shared parse stage the formats would otherwise redundantly pay for, and what
+	// keeps the per-goroutine copies below from racing on the original content.
+	c.enrichMetadata(ctx, req)
+	c.prefetchImages(ctx, req)
+
+	results := make(map[string]*ConvertResult, len(formats))
+	errs := make(map[string]error)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, format := range formats {
+		backend := backends[format]
+		wg.Add(1)
+		go func(backend FormatBackend) {
+			defer wg.Done()
+			// Each backend gets its own *ConvertRequest so concurrent formats never
+			// read/write the same one - EnrichMetadata is already a no-op here (see
+			// above), but ConvertToEPUB/MOBI/AZW3 call enrichMetadata again
+			// regardless, and would otherwise race on req.Content.
+			reqCopy := *req
+			result, err := backend.Convert(ctx, &reqCopy)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[backend.Name()] = err
+				return
+			}
+			results[backend.Name()] = result
+		}(backend)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		c.logger.Error("converter", "ConvertAll: one or more formats failed", nil, map[string]interface{}{
+			"requested_formats": formats,
+			"failed_formats":    errs,
+			"succeeded_formats": len(results),
+		})
+		failed := make([]string, 0, len(errs))
+		for format, err := range errs {
+			failed = append(failed, fmt.Sprintf("%s: %v", format, err))
+		}
+		return results, fmt.Errorf("%d of %d formats failed: %v", len(errs), len(formats), failed)
+	}
+
+	return results, nil
+}
+
+// prefetchImages downloads every image req.Content references, once, into a
+// throwaway workdir - not to keep the files (they're discarded immediately after),
+// but to populate the shared ImageCache so the per-format ImageHandlers that run
+// inside the fan-out (html5, epub-native, kindle-native) each resolve the same URL
+// from disk instead of racing each other to the network. A no-op when the image
+// cache is disabled, since without it there's nothing for the per-format downloads
+// to share.
+func (c *Converter) prefetchImages(ctx context.Context, req *ConvertRequest) {
+	if c.imageCache == nil {
+		return
+	}
+
+	workDir, err := os.MkdirTemp(c.tempDir, "convertall-prefetch-*")
+	if err != nil {
+		c.logger.Warn("converter", "ConvertAll: failed to create prefetch work directory, images will be downloaded per-format", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+	defer os.RemoveAll(workDir)
+
+	prefetch := NewImageHandler(c.logger, workDir, c.imageCache).WithUploadedAssets(req.UploadedAssets)
+	if err := prefetch.ProcessImagesForHTML(ctx, req.Content); err != nil {
+		c.logger.Warn("converter", "ConvertAll: image prefetch failed, continuing - per-format conversions will still fetch directly", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+}
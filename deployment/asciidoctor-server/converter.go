@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -11,6 +12,8 @@ import (
 	"strings"
 	"time"
 	"unicode"
+
+	"github.com/nbd-wtf/go-nostr/nip19"
 )
 
 // Converter handles AsciiDoc to various format conversions
@@ -21,6 +24,13 @@ type Converter struct {
 	tempDir         string
 	asciidoctorPath string
 	ready           bool
+	pool            *WorkerPool // nil when the worker pool is disabled or failed to start
+	backend         Backend     // LocalBackend unless cfg.ConverterBackend selects a sandboxed one
+	fs              Fs          // where finished artifacts are stored; OsFs unless cfg.StorageBackend selects otherwise
+	cache           *ConversionCache // nil disables caching entirely
+	imageCache      *ImageCache // nil disables the remote image cache; see image_cache.go
+	metadataEnricher *MetadataEnricher // see metadata_enrich.go; Enrich no-ops without a recognized provider
+	asciidoctorVersion string    // populated by verify(), included in cache keys
 }
 
 func NewConverter(logger *Logger, cfg Config) (converter *Converter, err error) {
@@ -67,6 +77,85 @@ func NewConverter(logger *Logger, cfg Config) (converter *Converter, err error)
 		})
 	}
 
+	// Start the persistent worker pool; any failure just falls back to the
+	// existing one-shot exec path rather than failing startup.
+	if pool, poolErr := NewWorkerPool(logger, cfg, asciidoctorPath); poolErr == nil {
+		converter.pool = pool
+	} else {
+		logger.Warn("converter", "Worker pool unavailable, falling back to one-shot asciidoctor exec per request", map[string]interface{}{
+			"error": poolErr.Error(),
+		})
+	}
+
+	converter.backend = resolveBackend(logger, cfg, asciidoctorPath)
+	converter.fs = newFs(context.Background(), logger, cfg)
+
+	if cfg.ConversionCacheEnabled {
+		converter.cache = NewConversionCache(converter.fs, logger, cfg.CacheMaxSizeMB*1024*1024, cfg.CacheTTL)
+	}
+
+	if cfg.ImageCacheEnabled {
+		converter.imageCache = NewImageCache(converter.fs, logger, cfg.ImageCacheMaxSizeMB*1024*1024)
+	}
+
+	converter.metadataEnricher = NewMetadataEnricher(logger, cfg)
+
+	return
+}
+
+// NewConverterEmbedded builds a Converter backed by EmbeddedBackend instead of
+// NewConverter's host-Ruby requirement, for deployments that want a single Go binary
+// with no Bundler/gem install step. Unlike NewConverter, a missing host asciidoctor is
+// not fatal here - it's the whole point of this constructor - so ConvertToHTML5 and
+// ConvertToDocBook5 work regardless, while ConvertToEPUB/ConvertToPDF/ConvertToMOBI/
+// ConvertToAZW3 fall back to whatever a host asciidoctor (if found) or the native Go
+// EPUB/MOBI backends can provide.
+func NewConverterEmbedded(logger *Logger, cfg Config) (converter *Converter, err error) {
+	embedded, err := NewEmbeddedBackend(logger)
+	if err != nil {
+		err = fmt.Errorf("failed to load embedded asciidoctor runtime: %w", err)
+		return
+	}
+
+	tempDir := filepath.Join(cfg.TempDir, "asciidoctor-server")
+	if err = os.MkdirAll(tempDir, FileModeDir); err != nil {
+		err = fmt.Errorf("failed to create temp directory: %w", err)
+		return
+	}
+
+	converter = &Converter{
+		logger:  logger,
+		config:  cfg,
+		timeout: cfg.ConversionTimeout,
+		tempDir: tempDir,
+		backend: embedded,
+		ready:   true,
+	}
+
+	if asciidoctorPath, findErr := findAsciidoctor(logger, cfg); findErr == nil {
+		converter.asciidoctorPath = asciidoctorPath
+	} else {
+		logger.Info("converter", "No host asciidoctor found; only HTML5/DocBook5 (via the embedded runtime) will be available", map[string]interface{}{
+			"error": findErr.Error(),
+		})
+	}
+
+	converter.fs = newFs(context.Background(), logger, cfg)
+	if cfg.ConversionCacheEnabled {
+		converter.cache = NewConversionCache(converter.fs, logger, cfg.CacheMaxSizeMB*1024*1024, cfg.CacheTTL)
+	}
+
+	if cfg.ImageCacheEnabled {
+		converter.imageCache = NewImageCache(converter.fs, logger, cfg.ImageCacheMaxSizeMB*1024*1024)
+	}
+
+	converter.metadataEnricher = NewMetadataEnricher(logger, cfg)
+
+	logger.Info("converter", "Asciidoctor converter initialized with embedded WASI runtime", map[string]interface{}{
+		"temp_dir": tempDir,
+		"timeout":  cfg.ConversionTimeout.String(),
+	})
+
 	return
 }
 
@@ -227,6 +316,8 @@ func (c *Converter) verify() (err error) {
 		"output": strings.TrimSpace(string(output)),
 	})
 
+	c.asciidoctorVersion = strings.TrimSpace(string(output))
+
 	return nil
 }
 
@@ -234,6 +325,46 @@ func (c *Converter) IsReady() bool {
 	return c.ready
 }
 
+// buildAsciidoctorCmd builds the exec.Cmd for running asciidoctor locally, handling
+// the "bundle exec" indirection the same way findAsciidoctor/verify already do.
+func buildAsciidoctorCmd(ctx context.Context, asciidoctorPath string, cfg Config, args []string) *exec.Cmd {
+	if asciidoctorPath != "bundle" {
+		return exec.CommandContext(ctx, asciidoctorPath, args...)
+	}
+
+	bundlePath, err := exec.LookPath("bundle")
+	if err != nil {
+		// Defer the failure to Run()/CombinedOutput() so callers see one consistent error path
+		return exec.CommandContext(ctx, "bundle", append([]string{"exec", "asciidoctor"}, args...)...)
+	}
+
+	cmd := exec.CommandContext(ctx, bundlePath, append([]string{"exec", "asciidoctor"}, args...)...)
+	if absGemfile, absErr := filepath.Abs(cfg.BundleGemfile); absErr == nil {
+		if _, statErr := os.Stat(absGemfile); statErr == nil {
+			cmd.Env = append(os.Environ(),
+				"BUNDLE_GEMFILE="+absGemfile,
+				"BUNDLE_PATH="+cfg.BundlePath,
+			)
+		}
+	}
+	return cmd
+}
+
+// asciidoctorArgsFromAttributes renders an attribute map (as built by
+// buildConversionAttributes) into asciidoctor CLI flags, e.g. {"toc": "", "doctype":
+// "book"} becomes ["-a", "toc", "-a", "doctype=book"].
+func asciidoctorArgsFromAttributes(attrs map[string]string) []string {
+	args := make([]string, 0, len(attrs)*2)
+	for key, value := range attrs {
+		if value == "" {
+			args = append(args, "-a", key)
+		} else {
+			args = append(args, "-a", fmt.Sprintf("%s=%s", key, value))
+		}
+	}
+	return args
+}
+
 // ValidateAndFixAsciiDoc validates AsciiDoc content and automatically fixes common issues
 // If no document header is found, it adds one using the provided metadata
 // Returns the (possibly modified) content and any error
@@ -244,6 +375,18 @@ func (c *Converter) ValidateAndFixAsciiDoc(content string, title string, authors
 		return "", fmt.Errorf("AsciiDoc content is empty")
 	}
 
+	// Normalize pubkey to hex up front - callers may pass either hex or npub1...,
+	// and decoding here means encodePubkeyToNpub below always receives the same
+	// canonical form, and a malformed pubkey is rejected instead of silently
+	// passed through to the generated header.
+	if pubkey != "" {
+		hexPubkey, err := decodeNpubToHex(pubkey)
+		if err != nil {
+			return "", fmt.Errorf("invalid pubkey: %w", err)
+		}
+		pubkey = hexPubkey
+	}
+
 	// Check if content starts with a document header (= Title)
 	// AsciiDoc documents should start with a level-0 heading
 	lines := strings.Split(trimmed, "\n")
@@ -407,35 +550,72 @@ func toTitleCase(s string) string {
 	return strings.Join(result, " ")
 }
 
-// encodePubkeyToNpub encodes a hex pubkey to npub format (bech32)
+// encodePubkeyToNpub encodes a hex pubkey to npub format (bech32) via nip19.EncodePublicKey
 // If pubkey is already in npub format, returns it as-is
-// If pubkey is hex (64 chars), attempts to encode to npub
-// Note: Full bech32 encoding requires a library. For now, accepts npub directly.
-// If hex is provided, returns it as-is (client should send npub format)
+// If pubkey is hex (64 chars) but invalid, returns it unchanged so callers can surface the value as-is
 func encodePubkeyToNpub(pubkey string) string {
 	if pubkey == "" {
 		return ""
 	}
-	
+
 	// If already in npub format, return as-is
 	if strings.HasPrefix(pubkey, "npub1") {
 		return pubkey
 	}
-	
-	// If it's hex format (64 characters), we need bech32 encoding
-	// For now, return hex as-is - client should send npub format
-	// TODO: Add bech32 library (e.g., github.com/nbd-wtf/go-nostr) for proper encoding
-	if len(pubkey) == 64 {
-		// Check if it's valid hex
-		if matched, _ := regexp.MatchString(`^[0-9a-fA-F]{64}$`, pubkey); matched {
-			// Return hex for now - proper npub encoding requires bech32 library
-			// Client should send npub format directly
-			return pubkey
-		}
+
+	if !isHexPubkey(pubkey) {
+		// Not hex and not npub - return as-is, nothing we can encode
+		return pubkey
 	}
-	
-	// Return as-is if we can't determine format
-	return pubkey
+
+	npub, err := nip19.EncodePublicKey(strings.ToLower(pubkey))
+	if err != nil {
+		// Leave the hex value untouched rather than failing the whole request
+		return pubkey
+	}
+
+	return npub
+}
+
+// decodeNpubToHex decodes an npub1... string back to its hex pubkey form
+// If pubkey is already hex, returns it as-is (after validating it)
+// Returns an error if the value is neither valid hex nor a valid npub
+func decodeNpubToHex(pubkey string) (string, error) {
+	if pubkey == "" {
+		return "", nil
+	}
+
+	if isHexPubkey(pubkey) {
+		return strings.ToLower(pubkey), nil
+	}
+
+	if !strings.HasPrefix(pubkey, "npub1") {
+		return "", fmt.Errorf("pubkey %q is neither 64-char hex nor npub1-prefixed bech32", pubkey)
+	}
+
+	prefix, value, err := nip19.Decode(pubkey)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode npub: %w", err)
+	}
+	if prefix != "npub" {
+		return "", fmt.Errorf("expected npub prefix, got %q", prefix)
+	}
+
+	hexPubkey, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("unexpected npub payload type %T", value)
+	}
+
+	return hexPubkey, nil
+}
+
+// isHexPubkey reports whether s is a 64-character lowercase-or-uppercase hex string
+func isHexPubkey(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	matched, _ := regexp.MatchString(`^[0-9a-fA-F]{64}$`, s)
+	return matched
 }
 
 // formatDate converts a date string (timestamp or YYYY-MM-DD) to YYYY-MM-DD format
@@ -493,6 +673,33 @@ type ConvertRequest struct {
 	CreatedAt   string   `json:"created_at,omitempty"`   // Creation date (YYYY-MM-DD or timestamp)
 	Image       string   `json:"image,omitempty"`         // Cover image URL
 	Theme       string   `json:"theme,omitempty"`
+
+	// IncludeMetadata requests that the structured document metadata (title, authors,
+	// revision, TOC) be extracted alongside the artifact and returned in ConvertResult.Metadata.
+	IncludeMetadata bool `json:"include_metadata,omitempty"`
+
+	// EnrichMetadata opts into MetadataEnricher filling in :author:/:revdate:/
+	// :description:/:front-cover-image: from an :isbn:/:google-books-id: attribute
+	// in Content before EPUB/MOBI/AZW3 generation - see Converter.enrichMetadata.
+	EnrichMetadata bool `json:"enrich_metadata,omitempty"`
+
+	// Formats is only read by the /convert/all endpoint - the format-specific
+	// endpoints ignore it and infer their format from the route.
+	Formats []string `json:"formats,omitempty"`
+
+	// ImageOptions controls how embedded remote images are resized/re-encoded
+	// before EmbedImagesAsBase64 - see image_transcode.go. Populated by the HTTP
+	// handler layer (which knows the target format and query string), not by
+	// request JSON, so it's excluded from (de)serialization. nil disables
+	// transcoding and embeds images as downloaded, matching pre-chunk3-3 behavior.
+	ImageOptions *ImageTranscodeOptions `json:"-"`
+
+	// UploadedAssets holds image/asset parts bundled with a multipart/form-data
+	// request (see parseMultipartConvertRequest in handlers.go), keyed by the
+	// original part filename (e.g. "diagram.png"). ImageHandler resolves a local
+	// image::diagram.png[] reference against this map before falling back to a
+	// remote fetch. nil for ordinary JSON requests.
+	UploadedAssets map[string][]byte `json:"-"`
 }
 
 // ConvertResult represents the result of a conversion
@@ -500,12 +707,69 @@ type ConvertResult struct {
 	FilePath string
 	Size     int64
 	MimeType string
+
+	// Metadata is populated only when the request set IncludeMetadata.
+	Metadata *DocumentMetadata `json:"metadata,omitempty"`
+
+	fs         Fs     // storage backend the artifact actually lives on
+	storageKey string // key/path to pass to fs.Open - may differ from FilePath (e.g. s3:// URL)
+}
+
+// Reader opens the converted artifact through whichever Fs produced it, so HTTP
+// handlers don't need to know whether FilePath is a local path or an s3:// URL.
+func (r *ConvertResult) Reader() (io.ReadCloser, error) {
+	if r.fs == nil {
+		return os.Open(r.FilePath)
+	}
+	return r.fs.Open(r.storageKey)
+}
+
+// finalizeConvertResult stores the locally-produced artifact at localPath through
+// Converter's configured Fs. With the default OsFs this is a no-op wrapper; with
+// MemMapFs/S3Fs it uploads the artifact and returns a ConvertResult whose FilePath is
+// an s3:// URL (or in-memory key) instead of a local filesystem path.
+func (c *Converter) finalizeConvertResult(localPath, mimeType string, size int64) (*ConvertResult, error) {
+	if _, ok := c.fs.(OsFs); ok {
+		return &ConvertResult{FilePath: localPath, Size: size, MimeType: mimeType, fs: c.fs, storageKey: localPath}, nil
+	}
+
+	data, err := os.ReadFile(localPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read converted artifact for upload: %w", err)
+	}
+
+	key := filepath.Base(localPath)
+	if err := c.fs.WriteFile(key, data, FileModeFile); err != nil {
+		return nil, fmt.Errorf("failed to store converted artifact: %w", err)
+	}
+
+	filePath := key
+	if s3fs, ok := c.fs.(*S3Fs); ok {
+		filePath = s3fs.URL(key)
+	}
+
+	return &ConvertResult{FilePath: filePath, Size: size, MimeType: mimeType, fs: c.fs, storageKey: key}, nil
 }
 
 func (c *Converter) ConvertToEPUB(ctx context.Context, req *ConvertRequest) (*ConvertResult, error) {
+	c.enrichMetadata(ctx, req)
 	return c.convert(ctx, req, "epub3", "epub")
 }
 
+// enrichMetadata runs MetadataEnricher.Enrich against req.Content when the caller
+// opted in via EnrichMetadata, mutating req in place before EPUB/MOBI/AZW3
+// generation. Called from ConvertToEPUB, ConvertToEPUBNative and
+// convertToKindleNativeUncached - the entry points that actually produce a
+// book-shaped artifact; convertViaEPUBUncached's Calibre fallback goes through one
+// of the first two for its EPUB intermediate, so it's covered without a fourth call
+// site.
+func (c *Converter) enrichMetadata(ctx context.Context, req *ConvertRequest) {
+	if !req.EnrichMetadata {
+		return
+	}
+	req.Content = c.metadataEnricher.Enrich(ctx, req.Content)
+}
+
 func (c *Converter) ConvertToPDF(ctx context.Context, req *ConvertRequest) (*ConvertResult, error) {
 	return c.convert(ctx, req, "pdf", "pdf")
 }
@@ -526,6 +790,16 @@ func (c *Converter) ConvertToAZW3(ctx context.Context, req *ConvertRequest) (*Co
 }
 
 func (c *Converter) convert(ctx context.Context, req *ConvertRequest, backend, extension string) (*ConvertResult, error) {
+	result, err := c.withCache(req, backend, extension, func() (*ConvertResult, error) {
+		return c.convertUncached(ctx, req, backend, extension)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.attachMetadataIfRequested(ctx, req, result), nil
+}
+
+func (c *Converter) convertUncached(ctx context.Context, req *ConvertRequest, backend, extension string) (*ConvertResult, error) {
 	// Create temp directory for this conversion
 	workDir, err := os.MkdirTemp(c.tempDir, "convert-*")
 	if err != nil {
@@ -542,6 +816,21 @@ func (c *Converter) convert(ctx context.Context, req *ConvertRequest, backend, e
 	// Determine output path
 	outputPath := filepath.Join(workDir, fmt.Sprintf("output.%s", extension))
 
+	if dockerBackend, ok := c.backend.(*DockerBackend); ok {
+		return c.convertViaBackend(ctx, dockerBackend, req, backend, extension, workDir, outputPath)
+	}
+
+	if embeddedBackend, ok := c.backend.(*EmbeddedBackend); ok {
+		if backend != "html5" && backend != "docbook5" {
+			return nil, fmt.Errorf("embedded backend does not support %q; only html5/docbook5 are bundled (see wasm/README.md)", backend)
+		}
+		return c.convertViaBackend(ctx, embeddedBackend, req, backend, extension, workDir, outputPath)
+	}
+
+	if c.pool != nil {
+		return c.convertViaPool(ctx, req, backend, extension, inputPath, outputPath)
+	}
+
 	// Build asciidoctor command
 	// If asciidoctorPath is "bundle", use bundle exec
 	var cmd *exec.Cmd
@@ -718,14 +1007,179 @@ func (c *Converter) convert(ctx context.Context, req *ConvertRequest, backend, e
 		"duration":     duration.String(),
 	})
 
-	return &ConvertResult{
-		FilePath: outputPath,
-		Size:     info.Size(),
-		MimeType: mimeType,
-	}, nil
+	return c.finalizeConvertResult(outputPath, mimeType, info.Size())
+}
+
+// buildConversionAttributes computes the asciidoctor attribute set shared by the exec
+// and worker-pool code paths (author/pubkey defaulting, version, dates, cover image).
+func buildConversionAttributes(req *ConvertRequest) map[string]string {
+	attrs := map[string]string{
+		"toc":            "",
+		"stem":           "",
+		"doctype":        "book",
+		"allow-uri-read": "",
+	}
+
+	authors := req.Authors
+	if len(authors) == 0 && req.Author != "" {
+		authors = []string{req.Author}
+	} else if len(authors) == 0 && req.Pubkey != "" {
+		authors = []string{encodePubkeyToNpub(req.Pubkey)}
+	}
+	if len(authors) > 0 {
+		attrs["author"] = strings.Join(authors, "; ")
+	}
+
+	versionValue := req.Version
+	if versionValue == "" {
+		versionValue = "1.0"
+	}
+	attrs["revnumber"] = versionValue
+
+	if req.PublishedOn != "" {
+		attrs["revdate"] = req.PublishedOn
+	}
+
+	if req.CreatedAt != "" {
+		if createdDate := formatDate(req.CreatedAt); createdDate != "" {
+			attrs["created"] = createdDate
+		}
+	}
+
+	hasExplicitAuthors := len(req.Authors) > 0 || req.Author != ""
+	if req.Pubkey != "" && hasExplicitAuthors {
+		attrs["pubkey"] = encodePubkeyToNpub(req.Pubkey)
+	}
+
+	if req.Image != "" {
+		attrs["front-cover-image"] = req.Image
+	}
+
+	attrs["title"] = req.Title
+
+	return attrs
+}
+
+// convertViaBackend runs a conversion through a sandboxed Backend (currently only
+// DockerBackend) instead of exec'ing asciidoctor directly on the host.
+func (c *Converter) convertViaBackend(ctx context.Context, b Backend, req *ConvertRequest, backendName, extension, workDir, outputPath string) (*ConvertResult, error) {
+	args := []string{"-b", backendName, "-D", workDir, "-o", filepath.Base(outputPath)}
+	args = append(args, asciidoctorArgsFromAttributes(buildConversionAttributes(req))...)
+	args = append(args, "input.adoc")
+
+	c.logger.Info("converter", fmt.Sprintf("Starting %s conversion via sandboxed backend", backendName), map[string]interface{}{
+		"operation": "conversion",
+		"backend":   backendName,
+	})
+
+	startTime := time.Now()
+	output, err := b.Convert(ctx, workDir, args)
+	duration := time.Since(startTime)
+
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("conversion timeout after %s: %w", c.timeout, err)
+		}
+		c.logger.Error("converter", fmt.Sprintf("%s conversion failed", backendName), err, map[string]interface{}{
+			"error_type":     "conversion_failed",
+			"component":      "converter",
+			"operation":      "conversion",
+			"backend":        backendName,
+			"duration_ms":    duration.Milliseconds(),
+			"command_output": string(output),
+		})
+		return nil, fmt.Errorf("conversion failed: %w (output: %s)", err, string(output))
+	}
+
+	info, err := os.Stat(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("output file not created at %s: %w", outputPath, err)
+	}
+	if info.Size() == 0 {
+		return nil, fmt.Errorf("output file is empty")
+	}
+
+	c.logger.Info("converter", fmt.Sprintf("%s conversion completed", backendName), map[string]interface{}{
+		"operation":   "conversion",
+		"backend":     backendName,
+		"output_file": outputPath,
+		"output_size": info.Size(),
+		"duration_ms": duration.Milliseconds(),
+		"duration":    duration.String(),
+	})
+
+	return c.finalizeConvertResult(outputPath, getMimeType(extension), info.Size())
+}
+
+// convertViaPool runs a conversion through the persistent worker pool instead of
+// spawning a fresh asciidoctor process, falling back to ordinary error handling
+// identical to the exec path (timeout detection, output-file verification).
+func (c *Converter) convertViaPool(ctx context.Context, req *ConvertRequest, backend, extension, inputPath, outputPath string) (*ConvertResult, error) {
+	job := WorkerJob{
+		InputPath:  inputPath,
+		OutputPath: outputPath,
+		Backend:    backend,
+		Attributes: buildConversionAttributes(req),
+	}
+
+	c.logger.Info("converter", fmt.Sprintf("Starting %s conversion via worker pool", backend), map[string]interface{}{
+		"operation": "conversion",
+		"backend":   backend,
+		"pool":      c.pool.Stats(),
+	})
+
+	startTime := time.Now()
+	err := c.pool.Convert(ctx, job)
+	duration := time.Since(startTime)
+
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("conversion timeout after %s: %w", c.timeout, err)
+		}
+
+		c.logger.Error("converter", fmt.Sprintf("%s conversion failed", backend), err, map[string]interface{}{
+			"error_type":  "conversion_failed",
+			"component":   "converter",
+			"operation":   "conversion",
+			"backend":     backend,
+			"duration_ms": duration.Milliseconds(),
+		})
+
+		return nil, fmt.Errorf("conversion failed: %w", err)
+	}
+
+	info, err := os.Stat(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("output file not created at %s: %w", outputPath, err)
+	}
+	if info.Size() == 0 {
+		return nil, fmt.Errorf("output file is empty")
+	}
+
+	c.logger.Info("converter", fmt.Sprintf("%s conversion completed", backend), map[string]interface{}{
+		"operation":   "conversion",
+		"backend":     backend,
+		"output_file": outputPath,
+		"output_size": info.Size(),
+		"duration_ms": duration.Milliseconds(),
+		"duration":    duration.String(),
+		"pool":        c.pool.Stats(),
+	})
+
+	return c.finalizeConvertResult(outputPath, getMimeType(extension), info.Size())
 }
 
 func (c *Converter) convertHTML5(ctx context.Context, req *ConvertRequest) (*ConvertResult, error) {
+	result, err := c.withCache(req, "html5", "html", func() (*ConvertResult, error) {
+		return c.convertHTML5Uncached(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.attachMetadataIfRequested(ctx, req, result), nil
+}
+
+func (c *Converter) convertHTML5Uncached(ctx context.Context, req *ConvertRequest) (*ConvertResult, error) {
 	// Create temp directory for this conversion
 	workDir, err := os.MkdirTemp(c.tempDir, "convert-*")
 	if err != nil {
@@ -734,13 +1188,16 @@ func (c *Converter) convertHTML5(ctx context.Context, req *ConvertRequest) (*Con
 	defer os.RemoveAll(workDir) // Clean up entire work directory
 
 	// Process images for HTML embedding (download temporarily, but don't modify AsciiDoc content)
-	imageHandler := NewImageHandler(c.logger, workDir)
+	imageHandler := NewImageHandler(c.logger, workDir, c.imageCache).WithUploadedAssets(req.UploadedAssets)
 	if err := imageHandler.ProcessImagesForHTML(ctx, req.Content); err != nil {
 		c.logger.Warn("converter", "Failed to process some images, continuing with conversion", map[string]interface{}{
 			"error": err.Error(),
 		})
 		// Continue even if image processing fails - asciidoctor can still fetch them
 	}
+	if req.ImageOptions != nil {
+		imageHandler.TranscodeImages(*req.ImageOptions)
+	}
 	defer imageHandler.Cleanup() // Clean up downloaded images after embedding
 
 	// Create input file with ORIGINAL content (keep remote URLs as-is)
@@ -753,6 +1210,69 @@ func (c *Converter) convertHTML5(ctx context.Context, req *ConvertRequest) (*Con
 	// Determine output path
 	outputPath := filepath.Join(workDir, "output.html")
 
+	if embeddedBackend, ok := c.backend.(*EmbeddedBackend); ok {
+		attrs := buildConversionAttributes(req)
+		attrs["standalone"] = ""
+		attrs["imagesdir"] = "images"
+		args := []string{"-b", "html5", "-D", workDir, "-o", filepath.Base(outputPath)}
+		args = append(args, asciidoctorArgsFromAttributes(attrs)...)
+		args = append(args, filepath.Base(inputPath))
+
+		startTime := time.Now()
+		output, err := embeddedBackend.Convert(ctx, workDir, args)
+		duration := time.Since(startTime)
+		if err != nil {
+			c.logger.Error("converter", "html5 conversion via embedded backend failed", err, map[string]interface{}{
+				"error_type":     "conversion_failed",
+				"component":      "converter",
+				"operation":      "conversion",
+				"backend":        "html5",
+				"duration_ms":    duration.Milliseconds(),
+				"command_output": string(output),
+			})
+			return nil, fmt.Errorf("conversion failed: %w (output: %s)", err, string(output))
+		}
+		return c.finishHTML5Output(outputPath, req, imageHandler, duration)
+	}
+
+	if c.pool != nil {
+		attrs := buildConversionAttributes(req)
+		attrs["standalone"] = ""
+		attrs["imagesdir"] = "images"
+		job := WorkerJob{
+			InputPath:  inputPath,
+			OutputPath: outputPath,
+			Backend:    "html5",
+			Attributes: attrs,
+		}
+
+		c.logger.Info("converter", "Starting html5 conversion via worker pool", map[string]interface{}{
+			"operation": "conversion",
+			"backend":   "html5",
+			"pool":      c.pool.Stats(),
+		})
+
+		startTime := time.Now()
+		poolErr := c.pool.Convert(ctx, job)
+		duration := time.Since(startTime)
+
+		if poolErr != nil {
+			if ctx.Err() == context.DeadlineExceeded {
+				return nil, fmt.Errorf("conversion timeout after %s: %w", c.timeout, poolErr)
+			}
+			c.logger.Error("converter", "html5 conversion failed", poolErr, map[string]interface{}{
+				"error_type":  "conversion_failed",
+				"component":   "converter",
+				"operation":   "conversion",
+				"backend":     "html5",
+				"duration_ms": duration.Milliseconds(),
+			})
+			return nil, fmt.Errorf("conversion failed: %w", poolErr)
+		}
+
+		return c.finishHTML5Output(outputPath, req, imageHandler, duration)
+	}
+
 	// Build asciidoctor command
 	// If asciidoctorPath is "bundle", use bundle exec
 	var cmd *exec.Cmd
@@ -891,6 +1411,13 @@ func (c *Converter) convertHTML5(ctx context.Context, req *ConvertRequest) (*Con
 		return nil, fmt.Errorf("conversion failed: %w (output: %s)", err, string(output))
 	}
 
+	return c.finishHTML5Output(outputPath, req, imageHandler, duration)
+}
+
+// finishHTML5Output embeds downloaded images as base64, adds the cover image, and
+// wraps the asciidoctor output into a complete HTML document. Shared by the
+// worker-pool and one-shot exec html5 conversion paths.
+func (c *Converter) finishHTML5Output(outputPath string, req *ConvertRequest, imageHandler *ImageHandler, duration time.Duration) (*ConvertResult, error) {
 	// Read HTML output
 	htmlContent, err := os.ReadFile(outputPath)
 	if err != nil {
@@ -930,11 +1457,7 @@ func (c *Converter) convertHTML5(ctx context.Context, req *ConvertRequest) (*Con
 		"duration":     duration.String(),
 	})
 
-	return &ConvertResult{
-		FilePath: outputPath,
-		Size:     info.Size(),
-		MimeType: "text/html; charset=utf-8",
-	}, nil
+	return c.finalizeConvertResult(outputPath, "text/html; charset=utf-8", info.Size())
 }
 
 // ConvertToDocBook5 converts AsciiDoc content to DocBook5 XML
@@ -961,13 +1484,42 @@ func ensureCompleteHTML(htmlContent, title string) string {
 
 // convertViaEPUB converts AsciiDoc to Kindle format via EPUB intermediate
 func (c *Converter) convertViaEPUB(ctx context.Context, req *ConvertRequest, kindleFormat string) (*ConvertResult, error) {
-	// First, convert to EPUB
-	epubResult, err := c.ConvertToEPUB(ctx, req)
+	result, err := c.withCache(req, kindleFormat, kindleFormat, func() (*ConvertResult, error) {
+		return c.convertViaEPUBUncached(ctx, req, kindleFormat)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.attachMetadataIfRequested(ctx, req, result), nil
+}
+
+func (c *Converter) convertViaEPUBUncached(ctx context.Context, req *ConvertRequest, kindleFormat string) (*ConvertResult, error) {
+	// Prefer the pure-Go MOBI/PalmDoc writer when enabled, eliminating the Calibre
+	// dependency entirely. Any failure falls back to the ebook-convert path below.
+	if c.config.NativeKindle {
+		result, err := c.convertToKindleNativeUncached(ctx, req, kindleFormat)
+		if err == nil {
+			return result, nil
+		}
+		c.logger.Warn("converter", fmt.Sprintf("Native %s conversion failed, falling back to Calibre ebook-convert", kindleFormat), map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	// First, convert to EPUB (native Go backend or asciidoctor-epub3, per cfg.EPUBBackend)
+	epubResult, err := c.convertToEPUBForKindle(ctx, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate EPUB for %s conversion: %w", kindleFormat, err)
 	}
 	defer os.Remove(epubResult.FilePath) // Clean up EPUB after conversion
 
+	return c.runEbookConvert(ctx, epubResult, kindleFormat)
+}
+
+// runEbookConvert shells out to Calibre's ebook-convert to turn an already-generated
+// EPUB into kindleFormat ("mobi"/"azw3"). Split out of convertViaEPUBUncached so
+// ConvertStream can drive the same step after emitting its own progress events.
+func (c *Converter) runEbookConvert(ctx context.Context, epubResult *ConvertResult, kindleFormat string) (*ConvertResult, error) {
 	// Find Calibre's ebook-convert command
 	ebookConvertPath, err := findEbookConvert()
 	if err != nil {
@@ -1053,11 +1605,7 @@ func (c *Converter) convertViaEPUB(ctx context.Context, req *ConvertRequest, kin
 		"duration":     duration.String(),
 	})
 
-	return &ConvertResult{
-		FilePath: outputPath,
-		Size:     info.Size(),
-		MimeType: mimeType,
-	}, nil
+	return c.finalizeConvertResult(outputPath, mimeType, info.Size())
 }
 
 func findEbookConvert() (string, error) {
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const testHexPubkey = "9b83a817c5d41ba83180e4c96af15e89c2463cd13b3de3ab79a478fccd9de840"
+const testNpub = "npub1nwp6s9796sd6svvqunyk4u2738pyv0x38v7782me53u0envaapqq4ee9m6"
+
+func TestEncodePubkeyToNpub(t *testing.T) {
+	tests := []struct {
+		name   string
+		pubkey string
+		want   string
+	}{
+		{name: "hex encodes to npub", pubkey: testHexPubkey, want: testNpub},
+		{name: "uppercase hex encodes to npub", pubkey: strings.ToUpper(testHexPubkey), want: testNpub},
+		{name: "npub passes through unchanged", pubkey: testNpub, want: testNpub},
+		{name: "empty string passes through unchanged", pubkey: "", want: ""},
+		{name: "invalid hex passes through unchanged", pubkey: "not-a-pubkey", want: "not-a-pubkey"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := encodePubkeyToNpub(tt.pubkey)
+			if got != tt.want {
+				t.Errorf("encodePubkeyToNpub(%q) = %q, want %q", tt.pubkey, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeNpubToHex(t *testing.T) {
+	tests := []struct {
+		name    string
+		pubkey  string
+		want    string
+		wantErr bool
+	}{
+		{name: "npub decodes to hex", pubkey: testNpub, want: testHexPubkey},
+		{name: "hex passes through lowercased", pubkey: strings.ToUpper(testHexPubkey), want: testHexPubkey},
+		{name: "empty string returns empty, no error", pubkey: "", want: ""},
+		{name: "wrong-length hex is rejected", pubkey: "abcd", wantErr: true},
+		{name: "npub-prefixed garbage is rejected", pubkey: "npub1notbech32", wantErr: true},
+		{name: "neither hex nor npub is rejected", pubkey: "not-a-pubkey", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeNpubToHex(tt.pubkey)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("decodeNpubToHex(%q) = %q, nil; want error", tt.pubkey, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeNpubToHex(%q) returned unexpected error: %v", tt.pubkey, err)
+			}
+			if got != tt.want {
+				t.Errorf("decodeNpubToHex(%q) = %q, want %q", tt.pubkey, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeDecodeNpubRoundTrip(t *testing.T) {
+	npub := encodePubkeyToNpub(testHexPubkey)
+	hex, err := decodeNpubToHex(npub)
+	if err != nil {
+		t.Fatalf("decodeNpubToHex(%q) returned unexpected error: %v", npub, err)
+	}
+	if hex != testHexPubkey {
+		t.Errorf("round trip hex->npub->hex = %q, want %q", hex, testHexPubkey)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+//go:embed wasm/asciidoctor.wasm
+var embeddedWasmFS embed.FS
+
+// EmbeddedBackend runs asciidoctor inside a WASI-compiled Ruby+asciidoctor module
+// (see wasm/README.md) via wazero, instead of shelling out to a host Ruby/Bundler
+// install. It implements Backend the same way DockerBackend does, so it slots into
+// the same convertViaBackend dispatch - the only difference is where the process
+// actually runs.
+//
+// Only ConvertToHTML5 and ConvertToDocBook5 route through it today: the embedded
+// wasm bundle only needs to carry the asciidoctor gem itself, not asciidoctor-epub3
+// or asciidoctor-pdf, so EPUB/PDF/MOBI/AZW3 keep using the host asciidoctor (or their
+// own native Go backends) regardless of ConverterBackend.
+type EmbeddedBackend struct {
+	logger   *Logger
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+
+	// mu serializes Convert calls. wazero's Runtime is safe for concurrent use, but
+	// a *sequence* of compile-once/instantiate-per-call the way Convert does it
+	// isn't worth parallelizing here - conversions are CPU-bound in the module
+	// itself either way, and this keeps the implementation simple.
+	mu sync.Mutex
+}
+
+// NewEmbeddedBackend loads and compiles the embedded asciidoctor.wasm module. Compiling
+// is the expensive part, so it happens once here rather than per Convert call.
+func NewEmbeddedBackend(logger *Logger) (*EmbeddedBackend, error) {
+	ctx := context.Background()
+
+	wasmBytes, err := embeddedWasmFS.ReadFile("wasm/asciidoctor.wasm")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedded asciidoctor.wasm: %w", err)
+	}
+
+	runtime := wazero.NewRuntime(ctx)
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, runtime); err != nil {
+		runtime.Close(ctx)
+		return nil, fmt.Errorf("failed to instantiate WASI: %w", err)
+	}
+
+	compiled, err := runtime.CompileModule(ctx, wasmBytes)
+	if err != nil {
+		runtime.Close(ctx)
+		return nil, fmt.Errorf("failed to compile embedded asciidoctor.wasm: %w", err)
+	}
+
+	return &EmbeddedBackend{logger: logger, runtime: runtime, compiled: compiled}, nil
+}
+
+// Convert runs `asciidoctor args...` inside the embedded module, with workDir mounted
+// as its root filesystem - mirroring LocalBackend.Convert's argv/CombinedOutput shape
+// so callers (convertViaBackend, finishHTML5Output) don't need to know which backend
+// they're talking to.
+func (b *EmbeddedBackend) Convert(ctx context.Context, workDir string, args []string) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var output bytes.Buffer
+	config := wazero.NewModuleConfig().
+		WithArgs(append([]string{"asciidoctor"}, args...)...).
+		WithFSConfig(wazero.NewFSConfig().WithDirMount(workDir, "/")).
+		WithStdout(&output).
+		WithStderr(&output)
+
+	mod, err := b.runtime.InstantiateModule(ctx, b.compiled, config)
+	if mod != nil {
+		defer mod.Close(ctx)
+	}
+
+	var exitErr *sys.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 0 {
+		err = nil
+	}
+	if err != nil {
+		return output.Bytes(), fmt.Errorf("embedded asciidoctor run failed: %w", err)
+	}
+	return output.Bytes(), nil
+}
+
+// Verify runs `asciidoctor --version` inside the module to confirm it's actually
+// runnable, the same check LocalBackend.Verify does for the host CLI.
+func (b *EmbeddedBackend) Verify(ctx context.Context) error {
+	_, err := b.Convert(ctx, os.TempDir(), []string{"--version"})
+	return err
+}
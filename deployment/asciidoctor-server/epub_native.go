@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	epub "github.com/bmaupin/go-epub"
+)
+
+// ConvertToEPUBNative converts AsciiDoc to EPUB 3 directly in Go via go-epub,
+// bypassing the Ruby asciidoctor-epub3 toolchain entirely. It is considerably
+// faster than shelling out to asciidoctor-epub3 and drops the bundler/gem
+// dependency. convertViaEPUBUncached uses it for MOBI/AZW3 generation when
+// cfg.EPUBBackend is "native".
+func (c *Converter) ConvertToEPUBNative(ctx context.Context, req *ConvertRequest) (*ConvertResult, error) {
+	c.enrichMetadata(ctx, req)
+	result, err := c.withCache(req, "epub-native", "epub", func() (*ConvertResult, error) {
+		return c.convertToEPUBNativeUncached(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.attachMetadataIfRequested(ctx, req, result), nil
+}
+
+// convertToEPUBForKindle picks the EPUB used as the intermediate for MOBI/AZW3
+// conversion, honoring cfg.EPUBBackend.
+func (c *Converter) convertToEPUBForKindle(ctx context.Context, req *ConvertRequest) (*ConvertResult, error) {
+	if c.config.EPUBBackend == "native" {
+		return c.ConvertToEPUBNative(ctx, req)
+	}
+	return c.ConvertToEPUB(ctx, req)
+}
+
+func (c *Converter) convertToEPUBNativeUncached(ctx context.Context, req *ConvertRequest) (*ConvertResult, error) {
+	workDir, err := os.MkdirTemp(c.tempDir, "epub-native-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp work directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	inputPath := filepath.Join(workDir, "input.adoc")
+	if err := os.WriteFile(inputPath, []byte(req.Content), FileModeFile); err != nil {
+		return nil, fmt.Errorf("failed to write content to temp file: %w", err)
+	}
+
+	// AsciiDoc -> HTML5 via the same asciidoctor html5 backend already wired here;
+	// the EPUB packaging below is the only part done natively in Go.
+	htmlPath := filepath.Join(workDir, "body.html")
+	args := asciidoctorArgsFromAttributes(buildConversionAttributes(req))
+	args = append([]string{"-b", "html5", "-D", workDir, "-o", filepath.Base(htmlPath)}, args...)
+	args = append(args, filepath.Base(inputPath))
+
+	cmd := buildAsciidoctorCmd(ctx, c.asciidoctorPath, c.config, args)
+	cmd.Dir = workDir
+
+	startTime := time.Now()
+	output, err := cmd.CombinedOutput()
+	duration := time.Since(startTime)
+	if err != nil {
+		return nil, fmt.Errorf("html5 conversion for native EPUB failed: %w (output: %s)", err, string(output))
+	}
+
+	bodyHTML, err := os.ReadFile(htmlPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read intermediate HTML: %w", err)
+	}
+
+	imageHandler := NewImageHandler(c.logger, workDir, c.imageCache).WithUploadedAssets(req.UploadedAssets)
+	if err := imageHandler.ProcessImagesForHTML(ctx, req.Content); err != nil {
+		c.logger.Warn("converter", "Image processing failed for native EPUB, continuing without remote images", map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+	if req.ImageOptions != nil {
+		imageHandler.TranscodeImages(*req.ImageOptions)
+	}
+
+	title := req.Title
+	if title == "" {
+		title = "Document"
+	}
+	book := epub.NewEpub(title)
+
+	authors := req.Authors
+	if len(authors) == 0 && req.Author != "" {
+		authors = []string{req.Author}
+	} else if len(authors) == 0 && req.Pubkey != "" {
+		authors = []string{encodePubkeyToNpub(req.Pubkey)}
+	}
+	if len(authors) > 0 {
+		book.SetAuthor(strings.Join(authors, "; "))
+	}
+
+	if req.Description != "" {
+		book.SetDescription(req.Description)
+	} else if req.Summary != "" {
+		book.SetDescription(req.Summary)
+	}
+
+	html := string(bodyHTML)
+	for remoteURL, filename := range imageHandler.images {
+		localPath := filepath.Join(imageHandler.imageDir, filename)
+		epubImagePath, imgErr := book.AddImage(localPath, filename)
+		if imgErr != nil {
+			c.logger.Warn("converter", "Failed to embed image in native EPUB", map[string]interface{}{
+				"url":   remoteURL,
+				"error": imgErr.Error(),
+			})
+			continue
+		}
+		html = rewriteImageSrc(html, remoteURL, epubImagePath)
+		html = rewriteImageSrc(html, filename, epubImagePath)
+	}
+
+	if req.Image != "" {
+		if coverPath, coverErr := imageHandler.locateOrDownload(ctx, req.Image); coverErr == nil {
+			coverFilename := "cover" + filepath.Ext(coverPath)
+			if _, imgErr := book.AddImage(coverPath, coverFilename); imgErr == nil {
+				book.SetCover(coverFilename, "")
+			} else {
+				c.logger.Warn("converter", "Failed to embed native EPUB cover image", map[string]interface{}{
+					"image": req.Image,
+					"error": imgErr.Error(),
+				})
+			}
+		} else {
+			c.logger.Warn("converter", "Failed to fetch native EPUB cover image", map[string]interface{}{
+				"image": req.Image,
+				"error": coverErr.Error(),
+			})
+		}
+	}
+
+	if _, err := book.AddSection(html, title, "", ""); err != nil {
+		return nil, fmt.Errorf("failed to add EPUB section: %w", err)
+	}
+
+	outputPath := filepath.Join(workDir, "output.epub")
+	if err := book.Write(outputPath); err != nil {
+		return nil, fmt.Errorf("failed to write EPUB file: %w", err)
+	}
+
+	info, err := os.Stat(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("EPUB output file not found: %w", err)
+	}
+
+	c.logger.Info("converter", "Native EPUB conversion completed", map[string]interface{}{
+		"operation":   "conversion",
+		"backend":     "epub-native",
+		"output_size": info.Size(),
+		"duration_ms": duration.Milliseconds(),
+		"duration":    duration.String(),
+	})
+
+	return c.finalizeConvertResult(outputPath, getMimeType("epub"), info.Size())
+}
+
+// rewriteImageSrc replaces any img/src attribute value containing match with replacement.
+func rewriteImageSrc(html, match, replacement string) string {
+	re := regexp.MustCompile(fmt.Sprintf(`src=["']([^"']*%s[^"']*)["']`, regexp.QuoteMeta(match)))
+	return re.ReplaceAllStringFunc(html, func(string) string {
+		return fmt.Sprintf(`src="%s"`, replacement)
+	})
+}
+
+// locateOrDownload returns a local file path for image, downloading it first via ih if
+// it's a remote URL that hasn't already been fetched.
+func (ih *ImageHandler) locateOrDownload(ctx context.Context, image string) (string, error) {
+	if !ih.isRemoteURL(image) {
+		return image, nil
+	}
+	if filename, ok := ih.images[image]; ok {
+		return filepath.Join(ih.imageDir, filename), nil
+	}
+	if err := ih.downloadImage(ctx, image); err != nil {
+		return "", err
+	}
+	return filepath.Join(ih.imageDir, ih.images[image]), nil
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// Fs abstracts the filesystem operations Converter needs for temp/output storage, so
+// results can live on local disk, in memory (tests), or in S3 for deployments that run
+// behind a load balancer or want to hand artifacts straight to a downstream CDN.
+// Modeled after github.com/spf13/afero's Fs, trimmed to what this package actually uses.
+type Fs interface {
+	MkdirAll(path string, perm os.FileMode) error
+	WriteFile(path string, data []byte, perm os.FileMode) error
+	ReadFile(path string) ([]byte, error)
+	Open(path string) (io.ReadCloser, error)
+	Stat(path string) (size int64, err error)
+	Remove(path string) error
+	RemoveAll(path string) error
+}
+
+// OsFs is the default Fs, delegating straight to the os package - today's behavior.
+type OsFs struct{}
+
+func (OsFs) MkdirAll(path string, perm os.FileMode) error          { return os.MkdirAll(path, perm) }
+func (OsFs) WriteFile(path string, data []byte, perm os.FileMode) error {
+	return os.WriteFile(path, data, perm)
+}
+func (OsFs) ReadFile(path string) ([]byte, error) { return os.ReadFile(path) }
+func (OsFs) Open(path string) (io.ReadCloser, error) { return os.Open(path) }
+func (OsFs) Stat(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+func (OsFs) Remove(path string) error    { return os.Remove(path) }
+func (OsFs) RemoveAll(path string) error { return os.RemoveAll(path) }
+
+// MemMapFs is an in-memory Fs, primarily useful so unit tests don't have to touch the
+// real filesystem. Directories are implicit - MkdirAll is a no-op beyond bookkeeping.
+type MemMapFs struct {
+	mu    sync.RWMutex
+	files map[string][]byte
+}
+
+func NewMemMapFs() *MemMapFs {
+	return &MemMapFs{files: make(map[string][]byte)}
+}
+
+func (m *MemMapFs) MkdirAll(path string, perm os.FileMode) error { return nil }
+
+func (m *MemMapFs) WriteFile(path string, data []byte, perm os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cp := make([]byte, len(data))
+	copy(cp, data)
+	m.files[path] = cp
+	return nil
+}
+
+func (m *MemMapFs) ReadFile(path string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	data, ok := m.files[path]
+	if !ok {
+		return nil, fmt.Errorf("memmapfs: %s: no such file", path)
+	}
+	return data, nil
+}
+
+func (m *MemMapFs) Open(path string) (io.ReadCloser, error) {
+	data, err := m.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m *MemMapFs) Stat(path string) (int64, error) {
+	data, err := m.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(data)), nil
+}
+
+func (m *MemMapFs) Remove(path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.files, path)
+	return nil
+}
+
+func (m *MemMapFs) RemoveAll(path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for k := range m.files {
+		if len(k) >= len(path) && k[:len(path)] == path {
+			delete(m.files, k)
+		}
+	}
+	return nil
+}
+
+// S3Fs stores converted artifacts in an S3 bucket and hands back s3:// URLs as
+// ConvertResult.FilePath. Local paths written during conversion (asciidoctor needs a
+// real file to read/write) still go through a scratch OsFs; only the final artifact is
+// uploaded, via Put.
+type S3Fs struct {
+	client *s3.Client
+	bucket string
+	prefix string
+	local  OsFs
+}
+
+func NewS3Fs(ctx context.Context, bucket, prefix string) (*S3Fs, error) {
+	awsCfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	return &S3Fs{
+		client: s3.NewFromConfig(awsCfg),
+		bucket: bucket,
+		prefix: prefix,
+	}, nil
+}
+
+func (f *S3Fs) key(path string) string {
+	return f.prefix + path
+}
+
+func (f *S3Fs) MkdirAll(path string, perm os.FileMode) error {
+	return f.local.MkdirAll(path, perm)
+}
+
+// WriteFile uploads data under path's S3 key. Used for the final converted artifact;
+// scratch files asciidoctor itself reads/writes still live on local disk.
+func (f *S3Fs) WriteFile(path string, data []byte, perm os.FileMode) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	_, err := f.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(f.bucket),
+		Key:    aws.String(f.key(path)),
+		Body:   bytes.NewReader(data),
+	})
+	if err != nil {
+		return fmt.Errorf("s3 put object failed: %w", err)
+	}
+	return nil
+}
+
+func (f *S3Fs) ReadFile(path string) ([]byte, error) {
+	rc, err := f.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+func (f *S3Fs) Open(path string) (io.ReadCloser, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	out, err := f.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(f.bucket),
+		Key:    aws.String(f.key(path)),
+	})
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("s3 get object failed: %w", err)
+	}
+	return out.Body, nil
+}
+
+func (f *S3Fs) Stat(path string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	out, err := f.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(f.bucket),
+		Key:    aws.String(f.key(path)),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("s3 head object failed: %w", err)
+	}
+	return aws.ToInt64(out.ContentLength), nil
+}
+
+func (f *S3Fs) Remove(path string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	_, err := f.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(f.bucket),
+		Key:    aws.String(f.key(path)),
+	})
+	return err
+}
+
+// RemoveAll is best-effort: S3 has no directory delimiter semantics here, so we only
+// remove the exact key. Callers that need prefix deletion should list-and-delete
+// themselves; none of Converter's current call sites need that.
+func (f *S3Fs) RemoveAll(path string) error {
+	return f.Remove(path)
+}
+
+// URL returns the s3:// URL ConvertResult.FilePath is set to for S3-backed results.
+func (f *S3Fs) URL(path string) string {
+	return fmt.Sprintf("s3://%s/%s", f.bucket, f.key(path))
+}
+
+// newFs builds the configured Fs ("os", "mem", or "s3") for Converter to use.
+func newFs(ctx context.Context, logger *Logger, cfg Config) Fs {
+	switch cfg.StorageBackend {
+	case "s3":
+		s3fs, err := NewS3Fs(ctx, cfg.S3Bucket, cfg.S3Prefix)
+		if err != nil {
+			logger.Warn("converter", "S3 storage backend unavailable, falling back to local disk", map[string]interface{}{
+				"error": err.Error(),
+			})
+			return OsFs{}
+		}
+		return s3fs
+	case "mem":
+		return NewMemMapFs()
+	default:
+		return OsFs{}
+	}
+}
@@ -2,19 +2,27 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
+
+	"github.com/gorilla/mux"
 )
 
 func (s *Server) handleConvertEPUB(w http.ResponseWriter, r *http.Request) {
 	s.handleConvert(w, r, "epub", s.converter.ConvertToEPUB)
 }
 
+func (s *Server) handleConvertEPUBNative(w http.ResponseWriter, r *http.Request) {
+	s.handleConvert(w, r, "epub", s.converter.ConvertToEPUBNative)
+}
+
 func (s *Server) handleConvertPDF(w http.ResponseWriter, r *http.Request) {
 	s.handleConvert(w, r, "pdf", s.converter.ConvertToPDF)
 }
@@ -35,15 +43,295 @@ func (s *Server) handleConvertDocBook5(w http.ResponseWriter, r *http.Request) {
 	s.handleConvert(w, r, "docbook5", s.converter.ConvertToDocBook5)
 }
 
+// handleMetadata extracts and returns structured document metadata (title, authors,
+// revision, TOC) without producing a rendered artifact - useful for Nostr event
+// tagging or library indexing when callers don't need a PDF/EPUB render.
+func (s *Server) handleMetadata(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	requestID := r.Context().Value("request_id").(string)
+
+	var req ConvertRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Error("http", "Failed to parse metadata extraction request", err, map[string]interface{}{
+			"request_id": requestID,
+			"error_type": "invalid_request",
+			"component":  "http_handler",
+			"operation":  "parse_request",
+		})
+
+		s.writeError(w, http.StatusBadRequest, "Invalid JSON", "Request body must be valid JSON with a 'content' field", requestID)
+		return
+	}
+
+	if req.Content == "" {
+		s.writeError(w, http.StatusBadRequest, "Missing content", "The 'content' field is required and cannot be empty", requestID)
+		return
+	}
+
+	if req.Title == "" {
+		req.Title = "Document"
+	}
+
+	authors := req.Authors
+	if len(authors) == 0 && req.Author != "" {
+		authors = []string{req.Author}
+	}
+
+	fixedContent, err := s.converter.ValidateAndFixAsciiDoc(req.Content, req.Title, authors, req.Pubkey, req.Version, req.Description, req.Summary, req.PublishedOn, req.CreatedAt)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid AsciiDoc",
+			fmt.Sprintf("AsciiDoc validation failed: %s", err.Error()),
+			requestID)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.converter.timeout)
+	defer cancel()
+
+	metadata, err := s.converter.ExtractMetadata(ctx, fixedContent)
+	if err != nil {
+		s.logger.Error("converter", "Metadata extraction failed", err, map[string]interface{}{
+			"request_id":  requestID,
+			"error_type":  "conversion_failed",
+			"component":   "converter",
+			"operation":   "extract_metadata",
+			"duration_ms": time.Since(startTime).Milliseconds(),
+		})
+
+		s.writeError(w, http.StatusInternalServerError, "Metadata extraction failed",
+			"Failed to extract document metadata. Check AsciiDoc syntax.",
+			requestID)
+		return
+	}
+
+	s.logger.Info("converter", "Metadata extraction completed successfully", map[string]interface{}{
+		"request_id":  requestID,
+		"duration_ms": time.Since(startTime).Milliseconds(),
+	})
+
+	s.writeJSON(w, http.StatusOK, metadata)
+}
+
+// handleConvertAll runs several output formats concurrently against one request
+// (see Converter.ConvertAll) - a Wikistr publish flow that wants HTML+EPUB+PDF+MOBI
+// no longer pays the sequential cost of four separate requests. Artifacts are
+// returned as base64 in the JSON body since the response carries several files.
+func (s *Server) handleConvertAll(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+	requestID := r.Context().Value("request_id").(string)
+
+	var req ConvertRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid JSON", "Request body must be valid JSON with 'content', 'title', and 'formats' fields", requestID)
+		return
+	}
+
+	if req.Content == "" {
+		s.writeError(w, http.StatusBadRequest, "Missing content", "The 'content' field is required and cannot be empty", requestID)
+		return
+	}
+	if len(req.Formats) == 0 {
+		s.writeError(w, http.StatusBadRequest, "Missing formats", "The 'formats' field is required and must list at least one format", requestID)
+		return
+	}
+	if req.Title == "" {
+		req.Title = "Document"
+	}
+
+	authors := req.Authors
+	if len(authors) == 0 && req.Author != "" {
+		authors = []string{req.Author}
+	}
+
+	fixedContent, err := s.converter.ValidateAndFixAsciiDoc(req.Content, req.Title, authors, req.Pubkey, req.Version, req.Description, req.Summary, req.PublishedOn, req.CreatedAt)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid AsciiDoc", fmt.Sprintf("AsciiDoc validation failed: %s", err.Error()), requestID)
+		return
+	}
+	req.Content = fixedContent
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.converter.timeout)
+	defer cancel()
+
+	results, err := s.converter.ConvertAll(ctx, &req, req.Formats)
+	if err != nil && len(results) == 0 {
+		s.logger.Error("converter", "ConvertAll failed for all formats", err, map[string]interface{}{
+			"request_id": requestID,
+			"formats":    req.Formats,
+		})
+		s.writeError(w, http.StatusInternalServerError, "Conversion failed", err.Error(), requestID)
+		return
+	}
+
+	artifacts := make(map[string]interface{}, len(results))
+	for format, result := range results {
+		file, readErr := result.Reader()
+		if readErr != nil {
+			s.logger.Warn("converter", "Failed to read ConvertAll artifact", map[string]interface{}{
+				"request_id": requestID,
+				"format":     format,
+				"error":      readErr.Error(),
+			})
+			continue
+		}
+		data, readErr := io.ReadAll(file)
+		file.Close()
+		if readErr != nil {
+			s.logger.Warn("converter", "Failed to buffer ConvertAll artifact", map[string]interface{}{
+				"request_id": requestID,
+				"format":     format,
+				"error":      readErr.Error(),
+			})
+			continue
+		}
+
+		artifacts[format] = map[string]interface{}{
+			"mime_type": result.MimeType,
+			"size":      result.Size,
+			"content":   base64.StdEncoding.EncodeToString(data),
+		}
+	}
+
+	response := map[string]interface{}{
+		"request_id":  requestID,
+		"artifacts":   artifacts,
+		"duration_ms": time.Since(startTime).Milliseconds(),
+	}
+	if err != nil {
+		response["partial_failure"] = err.Error()
+	}
+
+	s.logger.Info("converter", "ConvertAll completed", map[string]interface{}{
+		"request_id":  requestID,
+		"formats":     req.Formats,
+		"succeeded":   len(artifacts),
+		"duration_ms": time.Since(startTime).Milliseconds(),
+	})
+
+	s.writeJSON(w, http.StatusOK, response)
+}
+
 type convertFunc func(context.Context, *ConvertRequest) (*ConvertResult, error)
 
+// isMultipartRequest reports whether r's body should be parsed as multipart
+// form data rather than JSON.
+func isMultipartRequest(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data")
+}
+
+// multipartAssetFields lists the form field names a /convert/{format} multipart
+// request may bundle local images/assets under - both are treated identically,
+// "asset" just reads better for non-image attachments (fonts, etc.) referenced
+// from AsciiDoc. "images" (plural) deliberately avoids colliding with the
+// existing "image" text field (ConvertRequest.Image, the cover image URL).
+var multipartAssetFields = []string{"images", "asset"}
+
+// parseMultipartConvertRequest builds a ConvertRequest from a multipart/form-data
+// body: a required "source" part holds the AsciiDoc content, the same fields JSON
+// requests use (title, author, ...) are read as ordinary form values, and any
+// "images"/"asset" parts are collected into UploadedAssets keyed by their original
+// filename for ImageHandler to resolve local image::name.png[] references against.
+func parseMultipartConvertRequest(w http.ResponseWriter, r *http.Request) (*ConvertRequest, error) {
+	// Bound the whole body (source + every bundled asset), not just req.Content -
+	// ParseMultipartForm's memory limit only governs what's buffered before
+	// spilling to disk, not the total size of what a client can send.
+	r.Body = http.MaxBytesReader(w, r.Body, MaxContentSize)
+
+	if err := r.ParseMultipartForm(MaxMultipartMemory); err != nil {
+		return nil, fmt.Errorf("parsing multipart form: %w", err)
+	}
+
+	source := r.FormValue("source")
+	if source == "" {
+		return nil, fmt.Errorf("missing required 'source' part")
+	}
+
+	req := &ConvertRequest{
+		Content:     source,
+		Title:       r.FormValue("title"),
+		Author:      r.FormValue("author"),
+		Pubkey:      r.FormValue("pubkey"),
+		Version:     r.FormValue("version"),
+		Description: r.FormValue("description"),
+		Summary:     r.FormValue("summary"),
+		PublishedOn: r.FormValue("published_on"),
+		CreatedAt:   r.FormValue("created_at"),
+		Image:       r.FormValue("image"),
+		Theme:       r.FormValue("theme"),
+	}
+	if authors := r.Form["authors"]; len(authors) > 0 {
+		req.Authors = authors
+	}
+	if v := r.FormValue("include_metadata"); v == "true" || v == "1" {
+		req.IncludeMetadata = true
+	}
+	if v := r.FormValue("enrich_metadata"); v == "true" || v == "1" {
+		req.EnrichMetadata = true
+	}
+	if v := r.FormValue("formats"); v != "" {
+		req.Formats = strings.Split(v, ",")
+		for i := range req.Formats {
+			req.Formats[i] = strings.TrimSpace(req.Formats[i])
+		}
+	}
+
+	assets := make(map[string][]byte)
+	if r.MultipartForm != nil {
+		for _, field := range multipartAssetFields {
+			for _, header := range r.MultipartForm.File[field] {
+				if _, exists := assets[header.Filename]; exists {
+					return nil, fmt.Errorf("duplicate uploaded part filename %q - asset filenames must be unique across 'images' and 'asset' parts", header.Filename)
+				}
+
+				file, err := header.Open()
+				if err != nil {
+					return nil, fmt.Errorf("opening uploaded part %q: %w", header.Filename, err)
+				}
+				data, err := io.ReadAll(file)
+				file.Close()
+				if err != nil {
+					return nil, fmt.Errorf("reading uploaded part %q: %w", header.Filename, err)
+				}
+				assets[header.Filename] = data
+			}
+		}
+	}
+	if len(assets) > 0 {
+		req.UploadedAssets = assets
+	}
+
+	// Every part's bytes are already copied into assets above, so it's safe to
+	// drop net/http's spilled temp files now rather than leaking them until the
+	// process exits.
+	if r.MultipartForm != nil {
+		r.MultipartForm.RemoveAll()
+	}
+
+	return req, nil
+}
+
 func (s *Server) handleConvert(w http.ResponseWriter, r *http.Request, format string, convertFn convertFunc) {
 	startTime := time.Now()
 	requestID := r.Context().Value("request_id").(string)
 
-	// Parse request body
+	// Parse request body - multipart/form-data (source + bundled image/asset parts)
+	// or the ordinary JSON body.
 	var req ConvertRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if isMultipartRequest(r) {
+		parsed, err := parseMultipartConvertRequest(w, r)
+		if err != nil {
+			s.logger.Error("http", fmt.Sprintf("Failed to parse %s multipart conversion request", format), err, map[string]interface{}{
+				"request_id": requestID,
+				"error_type": "invalid_request",
+				"component":  "http_handler",
+				"operation":  "parse_multipart_request",
+				"format":     format,
+			})
+			s.writeError(w, http.StatusBadRequest, "Invalid multipart request", err.Error(), requestID)
+			return
+		}
+		req = *parsed
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		s.logger.Error("http", fmt.Sprintf("Failed to parse %s conversion request", format), err, map[string]interface{}{
 			"request_id":  requestID,
 			"error_type":  "invalid_request",
@@ -94,6 +382,8 @@ func (s *Server) handleConvert(w http.ResponseWriter, r *http.Request, format st
 	// Validate and fix AsciiDoc syntax (auto-adds document header if missing)
 	fixedContent, err := s.converter.ValidateAndFixAsciiDoc(req.Content, req.Title, authors, req.Pubkey, req.Version, req.Description, req.Summary, req.PublishedOn, req.CreatedAt)
 	if err != nil {
+		s.metrics.IncValidationFailure()
+
 		s.logger.Error("converter", fmt.Sprintf("AsciiDoc validation failed for %s conversion", format), err, map[string]interface{}{
 			"request_id":  requestID,
 			"error_type":  "validation_failed",
@@ -111,15 +401,54 @@ func (s *Server) handleConvert(w http.ResponseWriter, r *http.Request, format st
 	// Use the fixed content (may have had header added)
 	req.Content = fixedContent
 
+	// Resolve image transcoding options now, while format and query are both in
+	// scope - downstream conversion code only sees ConvertRequest, not the HTTP
+	// request. See imageTranscodeOptionsForRequest in image_transcode.go.
+	imageOpts := s.imageTranscodeOptionsForRequest(format, r.URL.Query())
+	req.ImageOptions = &imageOpts
+
+	// ?stream=sse relays live ConvertStream progress as Server-Sent Events instead of
+	// blocking until the whole conversion finishes - see handleConvertSSE.
+	if r.URL.Query().Get("stream") == "sse" {
+		s.handleConvertSSE(w, r, format, &req, requestID)
+		return
+	}
+
+	// ?async=1 hands the now-validated request to the same JobQueue that backs
+	// POST /jobs/{format}, instead of blocking this connection for the conversion.
+	// Useful for callers who'd rather keep using /convert/{format} than switch
+	// endpoints, but still want to dodge a multi-minute open HTTP connection.
+	if r.URL.Query().Get("async") == "1" {
+		job, err := s.jobs.Submit(&req, format, requestID)
+		if err != nil {
+			s.writeError(w, http.StatusBadRequest, "Job submission failed", err.Error(), requestID)
+			return
+		}
+		w.Header().Set("Location", fmt.Sprintf("/jobs/%s", job.ID))
+		s.writeJSON(w, http.StatusAccepted, map[string]interface{}{
+			"job_id":     job.ID,
+			"status":     JobQueued,
+			"status_url": fmt.Sprintf("/jobs/%s", job.ID),
+		})
+		return
+	}
+
 	// Create context with timeout
 	ctx, cancel := context.WithTimeout(r.Context(), s.converter.timeout)
 	defer cancel()
 
-	// Perform conversion
-	result, err := convertFn(ctx, &req)
+	// Perform conversion, retrying transient failures per s.retryConfig (see retry.go)
+	result, retries, err := withRetry(ctx, s.retryConfig, s.logger, map[string]interface{}{
+		"request_id": requestID,
+		"format":     format,
+	}, func(attemptCtx context.Context) (*ConvertResult, error) {
+		return convertFn(attemptCtx, &req)
+	})
 	if err != nil {
 		// Check if it's a timeout
 		if ctx.Err() == context.DeadlineExceeded {
+			s.metrics.ObserveConvert(format, "timeout", time.Since(startTime).Seconds())
+
 			s.logger.Error("converter", fmt.Sprintf("%s conversion timed out", format), err, map[string]interface{}{
 				"request_id":  requestID,
 				"error_type":  "conversion_timeout",
@@ -135,12 +464,15 @@ func (s *Server) handleConvert(w http.ResponseWriter, r *http.Request, format st
 			return
 		}
 
+		s.metrics.ObserveConvert(format, "error", time.Since(startTime).Seconds())
+
 		s.logger.Error("converter", fmt.Sprintf("%s conversion failed", format), err, map[string]interface{}{
 			"request_id":  requestID,
 			"error_type":  "conversion_failed",
 			"component":   "converter",
 			"operation":   "conversion",
 			"format":      format,
+			"retries":     retries,
 			"duration_ms": time.Since(startTime).Milliseconds(),
 		})
 
@@ -150,8 +482,8 @@ func (s *Server) handleConvert(w http.ResponseWriter, r *http.Request, format st
 		return
 	}
 
-	// Read result file
-	file, err := os.Open(result.FilePath)
+	// Read result file through whichever Fs produced it (local disk, memory, or S3)
+	file, err := result.Reader()
 	if err != nil {
 		s.logger.Error("converter", fmt.Sprintf("Failed to open %s output file", format), err, map[string]interface{}{
 			"request_id":  requestID,
@@ -195,15 +527,248 @@ func (s *Server) handleConvert(w http.ResponseWriter, r *http.Request, format st
 	}
 
 	// Log success
-	s.logger.Info("converter", fmt.Sprintf("%s conversion completed successfully", format), map[string]interface{}{
+	s.metrics.ObserveConvert(format, "success", time.Since(startTime).Seconds())
+	s.metrics.ObserveConvertSizes(int64(contentSize), result.Size)
+
+	completionFields := map[string]interface{}{
 		"request_id":  requestID,
 		"format":      format,
 		"output_size": result.Size,
+		"retries":     retries,
 		"duration_ms": time.Since(startTime).Milliseconds(),
 		"duration":    time.Since(startTime).String(),
+	}
+	if principal := principalFromContext(r.Context()); principal != nil {
+		completionFields["principal"] = principal.Subject
+	}
+	s.logger.Info("converter", fmt.Sprintf("%s conversion completed successfully", format), completionFields)
+}
+
+// handleConvertSSE drives Converter.ConvertStream and relays its progress as
+// Server-Sent Events instead of handleConvert's block-until-done response. request
+// has already been parsed and validated by handleConvert. The finished artifact is
+// registered with the same JobQueue that backs async jobs (see JobQueue.RegisterResult)
+// so the final "result" event can hand back a one-shot download URL through the
+// existing GET /jobs/{id}/result endpoint, rather than streaming the (possibly large)
+// artifact bytes over the SSE connection itself.
+func (s *Server) handleConvertSSE(w http.ResponseWriter, r *http.Request, format string, req *ConvertRequest, requestID string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.writeError(w, http.StatusInternalServerError, "Streaming unsupported", "This server cannot stream Server-Sent Events", requestID)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.converter.timeout)
+	defer cancel()
+
+	// Reserved up front so ConvertStream's on-disk job directory (<TempDir>/jobs/<id>)
+	// matches the Job RegisterResult creates once EventCompleted arrives below.
+	jobID := NewJobID()
+	ch, err := s.converter.ConvertStream(ctx, req, format, jobID)
+	if err != nil {
+		writeSSE(w, "warning", map[string]interface{}{"message": err.Error()})
+		flusher.Flush()
+		return
+	}
+
+	sentFetchingImages := false
+	for evt := range ch {
+		switch evt.Type {
+		case EventImageDownloadStarted:
+			if sentFetchingImages {
+				continue
+			}
+			sentFetchingImages = true
+			writeSSE(w, "stage", map[string]interface{}{"stage": "fetching_images"})
+		case EventImageDownloaded:
+			writeSSE(w, "image_downloaded", map[string]interface{}{"url": evt.URL, "bytes": evt.Bytes})
+		case EventAsciidoctorStarted:
+			writeSSE(w, "stage", map[string]interface{}{"stage": "asciidoctor"})
+		case EventEmbeddingStarted:
+			writeSSE(w, "stage", map[string]interface{}{"stage": "embedding"})
+		case EventWarning:
+			writeSSE(w, "warning", map[string]interface{}{"message": evt.Message})
+		case EventFailed:
+			message := "conversion failed"
+			if evt.Err != nil {
+				message = evt.Err.Error()
+			}
+			writeSSE(w, "warning", map[string]interface{}{"message": message})
+		case EventCompleted:
+			writeSSE(w, "done", map[string]interface{}{"size": evt.Result.Size, "content_type": evt.Result.MimeType})
+			job := s.jobs.RegisterResult(jobID, format, requestID, evt.Result)
+			writeSSE(w, "result", map[string]interface{}{"download_url": fmt.Sprintf("/jobs/%s/result", job.ID)})
+		}
+		flusher.Flush()
+	}
+
+	s.logger.Info("converter", fmt.Sprintf("%s SSE conversion completed", format), map[string]interface{}{
+		"request_id": requestID,
+		"job_id":     jobID,
+		"format":     format,
+	})
+}
+
+// handleSubmitJob enqueues an async conversion for the given format and returns 202
+// with a job_id, instead of holding the connection open the way handleConvert does.
+// Request parsing/validation mirrors handleConvert exactly - only what happens after
+// validation (enqueue vs. block-and-stream) differs.
+func (s *Server) handleSubmitJob(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+	format := mux.Vars(r)["format"]
+
+	var req ConvertRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.logger.Error("http", fmt.Sprintf("Failed to parse %s job request", format), err, map[string]interface{}{
+			"request_id": requestID,
+			"error_type": "invalid_request",
+			"component":  "http_handler",
+			"operation":  "parse_request",
+			"format":     format,
+		})
+		s.writeError(w, http.StatusBadRequest, "Invalid JSON", "Request body must be valid JSON with 'content' and 'title' fields", requestID)
+		return
+	}
+
+	if req.Content == "" {
+		s.writeError(w, http.StatusBadRequest, "Missing content", "The 'content' field is required and cannot be empty", requestID)
+		return
+	}
+	if req.Title == "" {
+		req.Title = "Document"
+	}
+
+	contentSize := len(req.Content)
+	if contentSize > MaxContentSize {
+		s.writeError(w, http.StatusRequestEntityTooLarge, "Content too large",
+			fmt.Sprintf("Content size (%d bytes) exceeds maximum allowed size (%d bytes)", contentSize, MaxContentSize),
+			requestID)
+		return
+	}
+
+	authors := req.Authors
+	if len(authors) == 0 && req.Author != "" {
+		authors = []string{req.Author}
+	}
+
+	fixedContent, err := s.converter.ValidateAndFixAsciiDoc(req.Content, req.Title, authors, req.Pubkey, req.Version, req.Description, req.Summary, req.PublishedOn, req.CreatedAt)
+	if err != nil {
+		s.writeError(w, http.StatusBadRequest, "Invalid AsciiDoc",
+			fmt.Sprintf("AsciiDoc validation failed: %s", err.Error()),
+			requestID)
+		return
+	}
+	req.Content = fixedContent
+
+	imageOpts := s.imageTranscodeOptionsForRequest(format, r.URL.Query())
+	req.ImageOptions = &imageOpts
+
+	job, err := s.jobs.Submit(&req, format, requestID)
+	if err != nil {
+		s.logger.Warn("jobs", "Failed to submit async conversion job", map[string]interface{}{
+			"request_id": requestID,
+			"format":     format,
+			"error":      err.Error(),
+		})
+		s.writeError(w, http.StatusBadRequest, "Job submission failed", err.Error(), requestID)
+		return
+	}
+
+	s.logger.Info("jobs", "Async conversion job queued", map[string]interface{}{
+		"request_id": requestID,
+		"job_id":     job.ID,
+		"format":     format,
+	})
+
+	s.writeJSON(w, http.StatusAccepted, map[string]interface{}{
+		"job_id":     job.ID,
+		"status":     JobQueued,
+		"status_url": fmt.Sprintf("/jobs/%s", job.ID),
 	})
 }
 
+// handleJobStatus reports a job's current status and progress for polling clients.
+func (s *Server) handleJobStatus(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+	id := mux.Vars(r)["id"]
+
+	job, ok := s.jobs.Get(id)
+	if !ok {
+		s.writeError(w, http.StatusNotFound, "Job not found", "No job with that ID exists or it has expired", requestID)
+		return
+	}
+
+	s.writeJSON(w, http.StatusOK, job.View())
+}
+
+// handleJobResult streams the finished artifact for a succeeded job, using the same
+// Content-Type/Content-Disposition/Content-Length logic as handleConvert. Unlike
+// handleConvert, the temp directory is NOT removed here - it lives until JobQueue's
+// TTL sweeper reclaims it, so the same result can be downloaded more than once.
+func (s *Server) handleJobResult(w http.ResponseWriter, r *http.Request) {
+	requestID := r.Context().Value("request_id").(string)
+	id := mux.Vars(r)["id"]
+
+	job, ok := s.jobs.Get(id)
+	if !ok {
+		s.writeError(w, http.StatusNotFound, "Job not found", "No job with that ID exists or it has expired", requestID)
+		return
+	}
+
+	status, result := job.resultAndStatus()
+	switch status {
+	case JobSucceeded:
+		// fall through to streaming below
+	case JobFailed:
+		s.writeError(w, http.StatusUnprocessableEntity, "Job failed", job.Err().Error(), requestID)
+		return
+	default:
+		s.writeError(w, http.StatusConflict, "Job not finished", fmt.Sprintf("Job is %s; poll GET /jobs/%s until it succeeds", status, id), requestID)
+		return
+	}
+
+	file, err := result.Reader()
+	if err != nil {
+		s.logger.Error("converter", "Failed to open job output file", err, map[string]interface{}{
+			"request_id": requestID,
+			"job_id":     id,
+			"file_path":  result.FilePath,
+		})
+		s.writeError(w, http.StatusInternalServerError, "Failed to read output",
+			"Conversion succeeded but failed to read the output file.",
+			requestID)
+		return
+	}
+	defer file.Close()
+
+	filename := sanitizeFilename(job.Format) + "." + filepath.Ext(result.FilePath)[1:]
+	w.Header().Set("Content-Type", result.MimeType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	// Serve via http.ServeContent when the underlying Fs hands back a seekable file
+	// (OsFs, the default) so a Range request can resume a download a flaky e-reader
+	// dropped partway through. Fs backends that don't support seeking (S3, mem) fall
+	// back to a plain full-body copy below.
+	if seeker, ok := file.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, "", time.Time{}, seeker)
+		return
+	}
+
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", result.Size))
+	if _, err := io.Copy(w, file); err != nil {
+		s.logger.Error("http", "Failed to stream job result", err, map[string]interface{}{
+			"request_id": requestID,
+			"job_id":     id,
+		})
+	}
+}
+
 func (s *Server) writeError(w http.ResponseWriter, statusCode int, errorType, message, requestID string) {
 	errorResponse := map[string]interface{}{
 		"error":      errorType,
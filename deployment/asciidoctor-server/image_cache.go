@@ -0,0 +1,235 @@
+package main
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ImageCache is a content-addressed store for remote images referenced from
+// AsciiDoc content, shared across every ImageHandler so the same image referenced
+// from multiple documents - or re-requested for the same wiki page - is downloaded
+// at most once. Blobs are keyed by SHA-256 of their bytes and sharded two hex chars
+// deep (mirroring git's object store) to keep any one directory small; a separate
+// per-URL sidecar records which digest a URL last resolved to plus its ETag/
+// Last-Modified, so a re-fetch can send a conditional request instead of a plain GET.
+//
+// As with ConversionCache, the in-memory LRU index only tracks what's needed for
+// size-based eviction and hit accounting - it's rebuilt empty on restart, so a
+// restart forgets access order but the blobs and URL sidecars already on fs survive.
+type ImageCache struct {
+	fs     Fs
+	logger *Logger
+
+	maxSizeBytes int64
+
+	mu        sync.Mutex
+	order     *list.List // front = most recently used
+	index     map[string]*list.Element
+	totalSize int64
+
+	hits    int64
+	entries int64
+}
+
+type imageCacheEntry struct {
+	digest   string
+	size     int64
+	storedAt time.Time
+}
+
+// imageBlobSidecar is the JSON metadata written alongside each cached image blob.
+type imageBlobSidecar struct {
+	MimeType string    `json:"mime_type"`
+	Size     int64     `json:"size"`
+	StoredAt time.Time `json:"stored_at"`
+}
+
+// imageURLEntry records what a URL last resolved to, so the next fetch of that URL
+// can send a conditional GET instead of downloading unconditionally.
+type imageURLEntry struct {
+	Digest       string `json:"digest"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	MimeType     string `json:"mime_type"`
+}
+
+// NewImageCache builds a cache that stores blobs and URL sidecars under fs, the same
+// storage backend Converter uses for finished artifacts. maxSizeBytes <= 0 disables
+// size-based eviction.
+func NewImageCache(fs Fs, logger *Logger, maxSizeBytes int64) *ImageCache {
+	return &ImageCache{
+		fs:           fs,
+		logger:       logger,
+		maxSizeBytes: maxSizeBytes,
+		order:        list.New(),
+		index:        make(map[string]*list.Element),
+	}
+}
+
+func digestHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func urlKey(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:])
+}
+
+func (ic *ImageCache) blobPath(digest string) string {
+	return fmt.Sprintf("images/%s/%s", digest[:2], digest)
+}
+
+func (ic *ImageCache) urlPath(key string) string {
+	return fmt.Sprintf("images/url/%s/%s.json", key[:2], key)
+}
+
+// LookupURL returns the URL entry last recorded for url, so the caller can attempt a
+// conditional GET against ETag/LastModified before falling back to a full download.
+func (ic *ImageCache) LookupURL(url string) (imageURLEntry, bool) {
+	data, err := ic.fs.ReadFile(ic.urlPath(urlKey(url)))
+	if err != nil {
+		return imageURLEntry{}, false
+	}
+	var entry imageURLEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return imageURLEntry{}, false
+	}
+	return entry, true
+}
+
+// Blob returns the cached bytes for digest, if still present, and bumps it to the
+// front of the LRU.
+func (ic *ImageCache) Blob(digest string) ([]byte, string, bool) {
+	path := ic.blobPath(digest)
+	data, err := ic.fs.ReadFile(path)
+	if err != nil {
+		return nil, "", false
+	}
+
+	mimeType := ""
+	if sidecarData, err := ic.fs.ReadFile(path + ".json"); err == nil {
+		var sidecar imageBlobSidecar
+		if json.Unmarshal(sidecarData, &sidecar) == nil {
+			mimeType = sidecar.MimeType
+		}
+	}
+
+	ic.mu.Lock()
+	if elem, tracked := ic.index[digest]; tracked {
+		ic.order.MoveToFront(elem)
+	}
+	ic.mu.Unlock()
+	atomic.AddInt64(&ic.hits, 1)
+
+	return data, mimeType, true
+}
+
+// Store writes data under its own SHA-256 digest (a no-op if that digest is already
+// cached - the common case for an image shared across documents) and records url as
+// resolving to it, along with revalidation headers pulled off resp for next time.
+// It returns the digest so the caller can materialize a local copy for embedding.
+func (ic *ImageCache) Store(url string, data []byte, mimeType string, resp *http.Response) string {
+	digest := digestHex(data)
+	path := ic.blobPath(digest)
+
+	// Reserve the index slot under the lock before touching fs, so two goroutines
+	// racing to store the same not-yet-cached digest don't both write the blob and
+	// both count its size - the loser of the race just skips straight to the URL
+	// sidecar below.
+	ic.mu.Lock()
+	_, alreadyTracked := ic.index[digest]
+	if !alreadyTracked {
+		elem := ic.order.PushFront(&imageCacheEntry{digest: digest, size: int64(len(data)), storedAt: time.Now()})
+		ic.index[digest] = elem
+	}
+	ic.mu.Unlock()
+
+	if !alreadyTracked {
+		if _, err := ic.fs.Stat(path); err != nil {
+			ic.fs.MkdirAll(filepath.Dir(path), FileModeDir)
+			if err := ic.fs.WriteFile(path, data, FileModeFile); err != nil {
+				ic.logger.Warn("image_cache", "Failed to store image blob", map[string]interface{}{
+					"digest": digest,
+					"error":  err.Error(),
+				})
+				return digest
+			}
+
+			sidecar := imageBlobSidecar{MimeType: mimeType, Size: int64(len(data)), StoredAt: time.Now()}
+			if sidecarData, err := json.Marshal(sidecar); err == nil {
+				ic.fs.WriteFile(path+".json", sidecarData, FileModeFile)
+			}
+		}
+
+		ic.mu.Lock()
+		ic.totalSize += int64(len(data))
+		ic.entries++
+		evicted := ic.evictLocked()
+		ic.mu.Unlock()
+
+		for _, evictedDigest := range evicted {
+			ic.fs.Remove(ic.blobPath(evictedDigest))
+			ic.fs.Remove(ic.blobPath(evictedDigest) + ".json")
+		}
+	}
+
+	entry := imageURLEntry{Digest: digest, MimeType: mimeType}
+	if resp != nil {
+		entry.ETag = resp.Header.Get("ETag")
+		entry.LastModified = resp.Header.Get("Last-Modified")
+	}
+	if entryData, err := json.Marshal(entry); err == nil {
+		urlPath := ic.urlPath(urlKey(url))
+		ic.fs.MkdirAll(filepath.Dir(urlPath), FileModeDir)
+		ic.fs.WriteFile(urlPath, entryData, FileModeFile)
+	}
+
+	return digest
+}
+
+// evictLocked removes least-recently-used blobs until totalSize is within
+// maxSizeBytes. Caller must hold ic.mu. URL sidecars are left in place - they're tiny,
+// and a stale one just means the next fetch for that URL re-downloads and re-links.
+func (ic *ImageCache) evictLocked() []string {
+	if ic.maxSizeBytes <= 0 {
+		return nil
+	}
+
+	var evicted []string
+	for ic.totalSize > ic.maxSizeBytes {
+		back := ic.order.Back()
+		if back == nil {
+			break
+		}
+		entry := back.Value.(*imageCacheEntry)
+		ic.order.Remove(back)
+		delete(ic.index, entry.digest)
+		ic.totalSize -= entry.size
+		ic.entries--
+		evicted = append(evicted, entry.digest)
+	}
+	return evicted
+}
+
+// Stats returns hit count, total cached bytes and entry count for handleHealth.
+func (ic *ImageCache) Stats() map[string]interface{} {
+	ic.mu.Lock()
+	bytes := ic.totalSize
+	entries := ic.entries
+	ic.mu.Unlock()
+
+	return map[string]interface{}{
+		"cache_hits":    atomic.LoadInt64(&ic.hits),
+		"cache_bytes":   bytes,
+		"cache_entries": entries,
+	}
+}
@@ -25,9 +25,26 @@ type ImageHandler struct {
 	client   *http.Client
 	images   map[string]string // remote URL -> local filename
 	imageDir string
+
+	// cache, when non-nil, is consulted before every download and populated after
+	// every successful one - see ImageCache in image_cache.go. nil disables it, and
+	// downloadImage falls back to downloading unconditionally on every call the way
+	// it always has.
+	cache *ImageCache
+
+	// events, when set via WithEvents, receives ImageDownloadStarted/ImageDownloaded
+	// as ProcessImagesForHTML downloads each remote image. nil (the default) disables
+	// it - the ordinary HTML5 conversion path doesn't need per-image progress events.
+	events chan<- ConvertEvent
+
+	// uploadedAssets holds image/asset bytes bundled with a multipart conversion
+	// request (see ConvertRequest.UploadedAssets), keyed by original filename. A
+	// local image::name.png[] reference is resolved against this map before
+	// ProcessImagesForHTML falls back to treating it as a remote URL.
+	uploadedAssets map[string][]byte
 }
 
-func NewImageHandler(logger *Logger, workDir string) *ImageHandler {
+func NewImageHandler(logger *Logger, workDir string, cache *ImageCache) *ImageHandler {
 	return &ImageHandler{
 		logger:  logger,
 		workDir: workDir,
@@ -35,9 +52,25 @@ func NewImageHandler(logger *Logger, workDir string) *ImageHandler {
 			Timeout: ImageHandlerTimeout,
 		},
 		images: make(map[string]string),
+		cache:  cache,
 	}
 }
 
+// WithEvents makes ih emit ImageDownloadStarted/ImageDownloaded events on ch as images
+// download, for callers streaming progress (see Converter.ConvertStream).
+func (ih *ImageHandler) WithEvents(ch chan<- ConvertEvent) *ImageHandler {
+	ih.events = ch
+	return ih
+}
+
+// WithUploadedAssets makes ih resolve image::name.png[] references against assets
+// (keyed by original filename) before falling back to a remote fetch - see
+// ConvertRequest.UploadedAssets.
+func (ih *ImageHandler) WithUploadedAssets(assets map[string][]byte) *ImageHandler {
+	ih.uploadedAssets = assets
+	return ih
+}
+
 // ProcessImagesForHTML scans content for images and downloads remote ones temporarily for HTML embedding
 // Does NOT modify the AsciiDoc content - keeps remote URLs as-is
 // Images are downloaded to temp files, embedded as base64, then deleted
@@ -65,15 +98,24 @@ func (ih *ImageHandler) ProcessImagesForHTML(ctx context.Context, content string
 		"note":            "Images downloaded temporarily, will be deleted after embedding",
 	})
 
-	// Download remote images temporarily (for base64 embedding only)
+	// Resolve images: an uploaded asset (bundled via multipart/form-data) wins over
+	// a remote fetch, since the client already has the bytes on hand.
 	for _, url := range allURLs {
+		if ih.materializeUploadedAsset(url) {
+			continue
+		}
 		if ih.isRemoteURL(url) {
+			ih.emitEvent(ctx, ConvertEvent{Type: EventImageDownloadStarted, URL: url})
 			if err := ih.downloadImage(ctx, url); err != nil {
 				ih.logger.Warn("image_handler", "Failed to download image", map[string]interface{}{
 					"url":   url,
 					"error": err.Error(),
 				})
 				// Continue with other images even if one fails
+				continue
+			}
+			if info, statErr := os.Stat(filepath.Join(ih.imageDir, ih.images[url])); statErr == nil {
+				ih.emitEvent(ctx, ConvertEvent{Type: EventImageDownloaded, URL: url, Bytes: info.Size()})
 			}
 		}
 	}
@@ -155,10 +197,12 @@ func (ih *ImageHandler) AddCoverImageToHTML(htmlContent, content string) string
 		"cover_image_path": coverImagePath,
 	})
 
-	// Check if we downloaded this image
+	// Check if we downloaded this image. Match against the source URL (the map key),
+	// not the local filename - TranscodeImages can rename the file (extension change
+	// on re-encode) while the URL it was downloaded from stays the same.
 	var localFilename string
-	for _, filename := range ih.images {
-		if filename == filepath.Base(coverImagePath) || strings.Contains(coverImagePath, filename) {
+	for sourceURL, filename := range ih.images {
+		if sourceURL == coverImagePath || strings.Contains(coverImagePath, filepath.Base(sourceURL)) {
 			localFilename = filename
 			break
 		}
@@ -253,11 +297,65 @@ func (ih *ImageHandler) extractCoverImage(content string) string {
 	return ""
 }
 
+// emitEvent forwards evt to ih.events, a no-op when no listener was attached via
+// WithEvents.
+func (ih *ImageHandler) emitEvent(ctx context.Context, evt ConvertEvent) {
+	if ih.events == nil {
+		return
+	}
+	emitEvent(ctx, ih.events, evt)
+}
+
 func (ih *ImageHandler) isRemoteURL(url string) bool {
 	return strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://")
 }
 
+// materializeUploadedAsset writes the uploaded asset matching a local image::
+// reference to disk under ih.imageDir and registers it in ih.images, exactly as
+// downloadImage would for a remote one. It matches on the reference as given and
+// on its base name, since image::diagram.png[] and image::images/diagram.png[]
+// should both resolve to an uploaded "diagram.png" part. Returns false (a no-op)
+// when there's no uploaded asset for this reference, or it isn't a bundled request.
+func (ih *ImageHandler) materializeUploadedAsset(ref string) bool {
+	if len(ih.uploadedAssets) == 0 {
+		return false
+	}
+
+	data, ok := ih.uploadedAssets[ref]
+	if !ok {
+		data, ok = ih.uploadedAssets[filepath.Base(ref)]
+	}
+	if !ok {
+		return false
+	}
+
+	filename := filepath.Base(ref)
+	localPath := filepath.Join(ih.imageDir, filename)
+	if err := os.WriteFile(localPath, data, FileModeFile); err != nil {
+		ih.logger.Warn("image_handler", "Failed to write uploaded asset", map[string]interface{}{
+			"reference": ref,
+			"error":     err.Error(),
+		})
+		return false
+	}
+
+	ih.images[ref] = filename
+	ih.logger.Debug("image_handler", "Resolved image reference against uploaded asset", map[string]interface{}{
+		"reference": ref,
+		"filename":  filename,
+		"size":      len(data),
+	})
+	return true
+}
+
 func (ih *ImageHandler) downloadImage(ctx context.Context, url string) error {
+	if ih.cache != nil {
+		if written, filename, err := ih.materializeFromCache(url); err == nil && written {
+			ih.images[url] = filename
+			return nil
+		}
+	}
+
 	ih.logger.Info("image_handler", "Downloading image", map[string]interface{}{
 		"url": url,
 	})
@@ -267,12 +365,49 @@ func (ih *ImageHandler) downloadImage(ctx context.Context, url string) error {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 
+	var cachedURLEntry imageURLEntry
+	haveCachedEntry := false
+	if ih.cache != nil {
+		if entry, ok := ih.cache.LookupURL(url); ok {
+			cachedURLEntry = entry
+			haveCachedEntry = true
+			if entry.ETag != "" {
+				req.Header.Set("If-None-Match", entry.ETag)
+			}
+			if entry.LastModified != "" {
+				req.Header.Set("If-Modified-Since", entry.LastModified)
+			}
+		}
+	}
+
 	resp, err := ih.client.Do(req)
 	if err != nil {
 		return fmt.Errorf("failed to download: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified && haveCachedEntry {
+		if written, filename, err := ih.writeBlobToDisk(url, cachedURLEntry.Digest, cachedURLEntry.MimeType); err == nil && written {
+			ih.images[url] = filename
+			return nil
+		}
+
+		// The server said 304, but the blob it's telling us to reuse is gone from
+		// our cache (evicted since the sidecar was written) - the conditional
+		// headers are worthless without it, so re-request unconditionally rather
+		// than treating 304 as a hard failure. The original response's body is
+		// still closed by the defer above.
+		req2, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		resp, err = ih.client.Do(req2)
+		if err != nil {
+			return fmt.Errorf("failed to download: %w", err)
+		}
+		defer resp.Body.Close()
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
@@ -281,31 +416,67 @@ func (ih *ImageHandler) downloadImage(ctx context.Context, url string) error {
 	filename := ih.getFilenameFromURL(url, resp)
 	localPath := filepath.Join(ih.imageDir, filename)
 
-	// Save file
-	file, err := os.Create(localPath)
+	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
+		return fmt.Errorf("failed to read response body: %w", err)
 	}
-	defer file.Close()
 
-	if _, err := io.Copy(file, resp.Body); err != nil {
+	if err := os.WriteFile(localPath, body, FileModeFile); err != nil {
 		return fmt.Errorf("failed to write file: %w", err)
 	}
 
+	if ih.cache != nil {
+		ih.cache.Store(url, body, ih.getMimeType(filename), resp)
+	}
+
 	// Store mapping
 	ih.images[url] = filename
 
-	info, _ := os.Stat(localPath)
 	ih.logger.Info("image_handler", "Image downloaded successfully", map[string]interface{}{
 		"url":        url,
 		"filename":   filename,
 		"local_path": localPath,
-		"size":       info.Size(),
+		"size":       len(body),
 	})
 
 	return nil
 }
 
+// materializeFromCache writes the blob url last resolved to (if any) to a local file
+// under ih.imageDir, skipping the network entirely. It's a best-effort shortcut - a
+// miss just falls through to the normal conditional-GET path in downloadImage.
+func (ih *ImageHandler) materializeFromCache(url string) (written bool, filename string, err error) {
+	entry, ok := ih.cache.LookupURL(url)
+	if !ok {
+		return false, "", nil
+	}
+	return ih.writeBlobToDisk(url, entry.Digest, entry.MimeType)
+}
+
+func (ih *ImageHandler) writeBlobToDisk(url, digest, mimeType string) (written bool, filename string, err error) {
+	data, cachedMimeType, ok := ih.cache.Blob(digest)
+	if !ok {
+		return false, "", nil
+	}
+	if mimeType == "" {
+		mimeType = cachedMimeType
+	}
+
+	filename = fmt.Sprintf("%s%s", digest[:16], extensionForMimeType(mimeType))
+	localPath := filepath.Join(ih.imageDir, filename)
+	if err := os.WriteFile(localPath, data, FileModeFile); err != nil {
+		return false, "", err
+	}
+
+	ih.logger.Info("image_handler", "Image served from cache", map[string]interface{}{
+		"url":    url,
+		"digest": digest,
+		"size":   len(data),
+	})
+
+	return true, filename, nil
+}
+
 func (ih *ImageHandler) getFilenameFromURL(url string, resp *http.Response) string {
 	// Try to get filename from URL
 	if filename := filepath.Base(url); filename != "" && filepath.Ext(filename) != "" {
@@ -365,6 +536,24 @@ func (ih *ImageHandler) getMimeType(filename string) string {
 	}
 }
 
+// extensionForMimeType is getMimeType run in reverse, for naming a file
+// materialized from a cached blob (which has no original URL/filename to infer an
+// extension from).
+func extensionForMimeType(mimeType string) string {
+	switch mimeType {
+	case "image/png":
+		return ".png"
+	case "image/gif":
+		return ".gif"
+	case "image/svg+xml":
+		return ".svg"
+	case "image/webp":
+		return ".webp"
+	default:
+		return ".jpg"
+	}
+}
+
 func (ih *ImageHandler) insertCoverImageHTML(htmlContent, imagePath, dataURI string) string {
 	var imgTag string
 	if dataURI != "" {
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/srwiley/oksvg"
+	"github.com/srwiley/rasterx"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // registers "webp" with image.Decode; encoding isn't supported upstream, so webp always transcodes out
+)
+
+// kindleTargets lists formats that reject or poorly support WebP, so ImageTranscoder
+// always converts WebP (and alpha-free PNG) to JPEG for them.
+var kindleTargets = map[string]bool{
+	"mobi": true,
+	"azw3": true,
+}
+
+// formatImageLimits are the built-in per-format maximum pixel dimensions, chosen for
+// typical e-reader/PDF viewer screen sizes. Config.ImageMaxWidth/ImageMaxHeight (or a
+// per-request query override) take precedence when set.
+var formatImageLimits = map[string][2]int{
+	"epub":  {1200, 1600},
+	"mobi":  {758, 1024},
+	"azw3":  {758, 1024},
+	"pdf":   {1600, 2200},
+	"html5": {1920, 1920},
+}
+
+// ImageTranscodeOptions controls how ImageHandler.TranscodeImages processes each
+// downloaded image before it's embedded. Zero values for MaxWidth/MaxHeight/
+// JPEGQuality mean "no limit" / "use the default" respectively.
+type ImageTranscodeOptions struct {
+	Format      string // target conversion format - see formatImageLimits/kindleTargets
+	MaxWidth    int
+	MaxHeight   int
+	JPEGQuality int
+	Grayscale   bool
+}
+
+// imageTranscodeOptionsForRequest builds ImageTranscodeOptions from the server's
+// configured defaults, format-specific limits, and finally per-request query
+// overrides (?image_max_width=, ?image_max_height=, ?image_jpeg_quality=,
+// ?image_grayscale=) - in that priority order, so a caller can always override the
+// server-wide defaults for one conversion.
+func (s *Server) imageTranscodeOptionsForRequest(format string, query url.Values) ImageTranscodeOptions {
+	opts := ImageTranscodeOptions{
+		Format:      format,
+		MaxWidth:    s.imageMaxWidth,
+		MaxHeight:   s.imageMaxHeight,
+		JPEGQuality: s.imageJpegQuality,
+		Grayscale:   s.imageGrayscale,
+	}
+
+	if limits, ok := formatImageLimits[format]; ok {
+		if opts.MaxWidth <= 0 || opts.MaxWidth > limits[0] {
+			opts.MaxWidth = limits[0]
+		}
+		if opts.MaxHeight <= 0 || opts.MaxHeight > limits[1] {
+			opts.MaxHeight = limits[1]
+		}
+	}
+
+	if v := query.Get("image_max_width"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			opts.MaxWidth = parsed
+		}
+	}
+	if v := query.Get("image_max_height"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 {
+			opts.MaxHeight = parsed
+		}
+	}
+	if v := query.Get("image_jpeg_quality"); v != "" {
+		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 && parsed <= 100 {
+			opts.JPEGQuality = parsed
+		}
+	}
+	if v := query.Get("image_grayscale"); v != "" {
+		opts.Grayscale = v == "true" || v == "1"
+	}
+
+	return opts
+}
+
+// TranscodeImages resizes, re-encodes and (for Kindle targets) reformats every image
+// ProcessImagesForHTML downloaded, overwriting the local temp file in place. It runs
+// after downloadImage and before EmbedImagesAsBase64, so the base64 payload embedded
+// in the output is the transcoded (usually much smaller) version, never the original.
+// A single image failing to transcode is logged and left as-is rather than aborting
+// the whole conversion - a slightly-too-large embedded image beats a failed one.
+func (ih *ImageHandler) TranscodeImages(opts ImageTranscodeOptions) {
+	if len(ih.images) == 0 {
+		return
+	}
+
+	for sourceURL, filename := range ih.images {
+		localPath := filepath.Join(ih.imageDir, filename)
+		data, err := os.ReadFile(localPath)
+		if err != nil {
+			continue
+		}
+
+		transcoded, newExt, err := transcodeImageBytes(data, opts)
+		if err != nil {
+			ih.logger.Warn("image_handler", "Failed to transcode image, embedding original", map[string]interface{}{
+				"url":   sourceURL,
+				"error": err.Error(),
+			})
+			continue
+		}
+
+		newFilename := strings.TrimSuffix(filename, filepath.Ext(filename)) + newExt
+		newPath := filepath.Join(ih.imageDir, newFilename)
+		if err := os.WriteFile(newPath, transcoded, FileModeFile); err != nil {
+			ih.logger.Warn("image_handler", "Failed to write transcoded image", map[string]interface{}{
+				"url":   sourceURL,
+				"error": err.Error(),
+			})
+			continue
+		}
+		if newPath != localPath {
+			os.Remove(localPath)
+		}
+		ih.images[sourceURL] = newFilename
+
+		ih.logger.Debug("image_handler", "Transcoded image for embedding", map[string]interface{}{
+			"url":            sourceURL,
+			"original_bytes": len(data),
+			"output_bytes":   len(transcoded),
+		})
+	}
+}
+
+// transcodeImageBytes decodes data (JPEG, PNG, GIF, WebP or SVG), resizes it to fit
+// within opts.MaxWidth/MaxHeight (preserving aspect ratio), optionally desaturates it
+// for e-ink displays, and re-encodes it. Re-encoding through Go's image package - as
+// opposed to copying bytes - is what strips EXIF/metadata: the decoded image.Image
+// carries no metadata for the encoder to write back out.
+func transcodeImageBytes(data []byte, opts ImageTranscodeOptions) (out []byte, ext string, err error) {
+	var img image.Image
+	sourceFormat := ""
+
+	if looksLikeSVG(data) {
+		img, err = rasterizeSVG(data, opts.MaxWidth, opts.MaxHeight)
+		sourceFormat = "svg"
+	} else {
+		img, sourceFormat, err = image.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("decoding image: %w", err)
+	}
+
+	img = resizeToFit(img, opts.MaxWidth, opts.MaxHeight)
+	if opts.Grayscale {
+		img = toGrayscale(img)
+	}
+
+	forceJPEG := kindleTargets[opts.Format] && (sourceFormat == "webp" || (sourceFormat == "png" && !hasAlpha(img)))
+	quality := opts.JPEGQuality
+	if quality <= 0 {
+		quality = DefaultImageJpegQuality
+	}
+
+	if sourceFormat == "png" && !forceJPEG {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, "", fmt.Errorf("encoding png: %w", err)
+		}
+		return buf.Bytes(), ".png", nil
+	}
+	if sourceFormat == "gif" && !forceJPEG {
+		var buf bytes.Buffer
+		if err := gif.Encode(&buf, img, nil); err != nil {
+			return nil, "", fmt.Errorf("encoding gif: %w", err)
+		}
+		return buf.Bytes(), ".gif", nil
+	}
+	if sourceFormat == "svg" {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, "", fmt.Errorf("encoding png: %w", err)
+		}
+		return buf.Bytes(), ".png", nil
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, "", fmt.Errorf("encoding jpeg: %w", err)
+	}
+	return buf.Bytes(), ".jpg", nil
+}
+
+// resizeToFit downscales img to fit within maxWidth x maxHeight, preserving aspect
+// ratio. It never upscales, and a zero/negative bound on either axis disables that
+// axis's constraint.
+func resizeToFit(img image.Image, maxWidth, maxHeight int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= 0 || height <= 0 {
+		return img
+	}
+
+	scale := 1.0
+	if maxWidth > 0 && width > maxWidth {
+		if s := float64(maxWidth) / float64(width); s < scale {
+			scale = s
+		}
+	}
+	if maxHeight > 0 && height > maxHeight {
+		if s := float64(maxHeight) / float64(height); s < scale {
+			scale = s
+		}
+	}
+	if scale >= 1.0 {
+		return img
+	}
+
+	dstWidth := int(float64(width) * scale)
+	dstHeight := int(float64(height) * scale)
+	if dstWidth < 1 {
+		dstWidth = 1
+	}
+	if dstHeight < 1 {
+		dstHeight = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+	return dst
+}
+
+// toGrayscale converts img to 8-bit grayscale, for e-ink displays that can't render
+// color anyway - halving (or better) the JPEG-encoded size versus keeping color data
+// the device will discard.
+func toGrayscale(img image.Image) image.Image {
+	bounds := img.Bounds()
+	gray := image.NewGray(bounds)
+	draw.Draw(gray, bounds, img, bounds.Min, draw.Src)
+	return gray
+}
+
+// hasAlpha reports whether any sampled pixel is partially or fully transparent. It
+// samples a bounded grid rather than every pixel - good enough to distinguish "has a
+// transparent background" from "fully opaque photo" without the cost of a full scan
+// on a large image.
+func hasAlpha(img image.Image) bool {
+	if paletted, ok := img.(*image.Paletted); ok {
+		for _, c := range paletted.Palette {
+			_, _, _, a := c.RGBA()
+			if a < 0xffff {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch img.(type) {
+	case *image.RGBA, *image.NRGBA:
+		// fall through to sampling below
+	default:
+		model := img.ColorModel()
+		if model != color.RGBAModel && model != color.NRGBAModel {
+			return false
+		}
+	}
+
+	bounds := img.Bounds()
+	const samplesPerAxis = 8
+	stepX := maxInt(1, bounds.Dx()/samplesPerAxis)
+	stepY := maxInt(1, bounds.Dy()/samplesPerAxis)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += stepY {
+		for x := bounds.Min.X; x < bounds.Max.X; x += stepX {
+			_, _, _, a := img.At(x, y).RGBA()
+			if a < 0xffff {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func looksLikeSVG(data []byte) bool {
+	head := data
+	if len(head) > 512 {
+		head = head[:512]
+	}
+	return bytes.Contains(head, []byte("<svg")) || bytes.Contains(head, []byte("<?xml"))
+}
+
+// rasterizeSVG renders an SVG document to a raster image.Image at up to maxWidth x
+// maxHeight, preserving aspect ratio.
+func rasterizeSVG(data []byte, maxWidth, maxHeight int) (image.Image, error) {
+	if maxWidth <= 0 {
+		maxWidth = DefaultImageMaxWidth
+	}
+	if maxHeight <= 0 {
+		maxHeight = DefaultImageMaxHeight
+	}
+
+	icon, err := oksvg.ReadIconStream(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("parsing SVG: %w", err)
+	}
+
+	width, height := int(icon.ViewBox.W), int(icon.ViewBox.H)
+	if width <= 0 || height <= 0 {
+		width, height = maxWidth, maxHeight
+	}
+	scale := 1.0
+	if width > maxWidth {
+		scale = float64(maxWidth) / float64(width)
+	}
+	if s := float64(maxHeight) / float64(height); height > maxHeight && s < scale {
+		scale = s
+	}
+	renderWidth := int(float64(width) * scale)
+	renderHeight := int(float64(height) * scale)
+
+	icon.SetTarget(0, 0, float64(renderWidth), float64(renderHeight))
+	dst := image.NewRGBA(image.Rect(0, 0, renderWidth, renderHeight))
+	scanner := rasterx.NewScannerGV(renderWidth, renderHeight, dst, dst.Bounds())
+	raster := rasterx.NewDasher(renderWidth, renderHeight, scanner)
+	icon.Draw(raster, 1.0)
+
+	return dst, nil
+}
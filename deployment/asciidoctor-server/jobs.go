@@ -0,0 +1,414 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// JobStatus is the lifecycle state of a Job.
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+)
+
+// Job tracks one async conversion submitted through POST /jobs/{format}. Progress
+// (Stage/BytesRead) is populated from the same ConvertEvent stream ConvertStream
+// already produces for the synchronous streaming path, rather than a second
+// notion of progress.
+type Job struct {
+	ID        string
+	Format    string
+	RequestID string
+
+	mu        sync.Mutex
+	status    JobStatus
+	stage     ConvertEventType
+	bytesRead int64
+	result    *ConvertResult
+	err       error
+	createdAt time.Time
+	updatedAt time.Time
+	ttl       time.Duration
+
+	req *ConvertRequest
+}
+
+// JobView is the JSON-safe snapshot returned by GET /jobs/{id}.
+type JobView struct {
+	ID         string    `json:"id"`
+	Format     string    `json:"format"`
+	Status     JobStatus `json:"status"`
+	Stage      string    `json:"stage,omitempty"`
+	Progress   float64   `json:"progress"`
+	BytesRead  int64     `json:"bytes_read,omitempty"`
+	OutputSize int64     `json:"output_size,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	ExpiresAt  time.Time `json:"expires_at,omitempty"`
+}
+
+// stageProgress is a best-effort ordering of ConvertEventType stages into a 0-1
+// fraction for JobView.Progress - not exact (a document with no images skips the
+// image stages entirely), but enough for a client-side progress bar.
+var stageProgress = map[ConvertEventType]float64{
+	EventImageDownloadStarted:    0.1,
+	EventImageDownloaded:         0.3,
+	EventAsciidoctorStarted:      0.5,
+	EventAsciidoctorStdoutLine:   0.7,
+	EventEmbeddingStarted:        0.8,
+	EventKindleConversionStarted: 0.85,
+	EventCompleted:               1.0,
+}
+
+// View returns a point-in-time snapshot safe to marshal as JSON without exposing
+// the mutex or the raw ConvertResult. ExpiresAt is only set once the job reaches a
+// terminal state, since that's when JobQueue's ttl sweeper starts the clock.
+func (j *Job) View() JobView {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	view := JobView{
+		ID:        j.ID,
+		Format:    j.Format,
+		Status:    j.status,
+		Stage:     string(j.stage),
+		BytesRead: j.bytesRead,
+		CreatedAt: j.createdAt,
+		UpdatedAt: j.updatedAt,
+	}
+	switch j.status {
+	case JobQueued:
+		view.Progress = 0
+	case JobSucceeded:
+		view.Progress = 1
+	default:
+		view.Progress = stageProgress[j.stage]
+	}
+	if j.result != nil {
+		view.OutputSize = j.result.Size
+	}
+	if j.status == JobSucceeded || j.status == JobFailed {
+		view.ExpiresAt = j.updatedAt.Add(j.ttl)
+	}
+	if j.err != nil {
+		view.Error = j.err.Error()
+	}
+	return view
+}
+
+func (j *Job) setStatus(status JobStatus) {
+	j.mu.Lock()
+	j.status = status
+	j.updatedAt = time.Now()
+	j.mu.Unlock()
+}
+
+func (j *Job) applyEvent(evt ConvertEvent) {
+	j.mu.Lock()
+	j.stage = evt.Type
+	if evt.Type == EventImageDownloaded {
+		j.bytesRead += evt.Bytes
+	}
+	j.updatedAt = time.Now()
+	j.mu.Unlock()
+}
+
+func (j *Job) succeed(result *ConvertResult) {
+	j.mu.Lock()
+	j.status = JobSucceeded
+	j.result = result
+	j.updatedAt = time.Now()
+	j.mu.Unlock()
+}
+
+func (j *Job) fail(err error) {
+	j.mu.Lock()
+	j.status = JobFailed
+	j.err = err
+	j.updatedAt = time.Now()
+	j.mu.Unlock()
+}
+
+// Err returns the failure reason set by fail, or nil if the job hasn't failed.
+func (j *Job) Err() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.err
+}
+
+// resultAndStatus returns the current status alongside the result, so callers
+// (the /jobs/{id}/result handler) can tell "not done yet" from "done, here it is"
+// under a single lock acquisition.
+func (j *Job) resultAndStatus() (JobStatus, *ConvertResult) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.status, j.result
+}
+
+func (j *Job) terminalAge() (terminal bool, age time.Duration) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	terminal = j.status == JobSucceeded || j.status == JobFailed
+	return terminal, time.Since(j.updatedAt)
+}
+
+// JobQueue is the bounded worker pool backing the async /jobs endpoints. It exists
+// so a large EPUB/PDF/MOBI conversion doesn't have to hold open the HTTP connection
+// for the whole ASCIIDOCTOR_CONVERSION_TIMEOUT window the way handleConvert does -
+// the client polls GET /jobs/{id} instead and downloads the result once it's ready.
+type JobQueue struct {
+	logger    *Logger
+	converter *Converter
+	ttl       time.Duration
+
+	tasks chan *Job
+
+	mu   sync.RWMutex
+	jobs map[string]*Job
+
+	stopCh chan struct{}
+}
+
+// NewJobQueue starts workers goroutines draining a queue of capacity queueSize, plus
+// a sweeper that removes terminal jobs (and their output files under TempDir) once
+// they've been sitting unclaimed longer than ttl.
+func NewJobQueue(logger *Logger, converter *Converter, workers, queueSize int, ttl time.Duration) *JobQueue {
+	jq := &JobQueue{
+		logger:    logger,
+		converter: converter,
+		ttl:       ttl,
+		tasks:     make(chan *Job, queueSize),
+		jobs:      make(map[string]*Job),
+		stopCh:    make(chan struct{}),
+	}
+
+	for i := 0; i < workers; i++ {
+		go jq.worker()
+	}
+	go jq.sweepLoop()
+
+	return jq
+}
+
+// NewJobID returns a fresh job identifier. Callers that need to reserve an ID before a
+// Job exists - handleConvertSSE drives Converter.ConvertStream itself and only
+// registers a Job once it completes - call this so the ID used for the on-disk job
+// directory (see jobWorkDir) matches the one the Job is later stored under.
+func NewJobID() string {
+	return uuid.New().String()
+}
+
+// jobWorkDir returns the disk-backed directory for job id under tempDir, creating it
+// if necessary - <TempDir>/jobs/<id>, holding that job's source, logs, and output blob
+// for its lifetime. It replaces the arbitrary os.MkdirTemp scratch dirs the synchronous
+// convert path uses, since a job's directory must stay addressable by ID across the
+// queued/running/succeeded lifecycle rather than being thrown away at the end of a
+// single function call. sweepExpired removes it once the job's TTL elapses.
+func jobWorkDir(tempDir, id string) (string, error) {
+	dir := filepath.Join(tempDir, "jobs", id)
+	if err := os.MkdirAll(dir, FileModeDir); err != nil {
+		return "", fmt.Errorf("failed to create job work directory: %w", err)
+	}
+	return dir, nil
+}
+
+// Submit validates format, registers a queued Job, and hands it to a worker. It
+// returns an error without enqueuing anything if format is unknown or the queue is
+// already at capacity - callers should surface both as 4xx, not retry internally.
+func (jq *JobQueue) Submit(req *ConvertRequest, format, requestID string) (*Job, error) {
+	if _, ok := jq.converter.formatBackends()[format]; !ok {
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+
+	job := &Job{
+		ID:        NewJobID(),
+		Format:    format,
+		RequestID: requestID,
+		status:    JobQueued,
+		createdAt: time.Now(),
+		updatedAt: time.Now(),
+		ttl:       jq.ttl,
+		req:       req,
+	}
+
+	jq.mu.Lock()
+	jq.jobs[job.ID] = job
+	jq.mu.Unlock()
+
+	select {
+	case jq.tasks <- job:
+		return job, nil
+	default:
+		jq.mu.Lock()
+		delete(jq.jobs, job.ID)
+		jq.mu.Unlock()
+		return nil, fmt.Errorf("job queue is full (capacity %d)", cap(jq.tasks))
+	}
+}
+
+// RegisterResult registers an already-finished result as a succeeded Job under id,
+// without going through the worker queue - used by the SSE streaming handler
+// (handleConvertSSE in handlers.go), which drives Converter.ConvertStream itself for
+// live progress and only needs the job store afterwards so its final "result" event
+// can hand back a one-shot download URL via the existing GET /jobs/{id}/result
+// endpoint. id must be the same one passed to ConvertStream, since that's what
+// ConvertStream used to name the job's on-disk directory.
+func (jq *JobQueue) RegisterResult(id, format, requestID string, result *ConvertResult) *Job {
+	now := time.Now()
+	job := &Job{
+		ID:        id,
+		Format:    format,
+		RequestID: requestID,
+		status:    JobSucceeded,
+		result:    result,
+		createdAt: now,
+		updatedAt: now,
+		ttl:       jq.ttl,
+	}
+
+	jq.mu.Lock()
+	jq.jobs[job.ID] = job
+	jq.mu.Unlock()
+
+	return job
+}
+
+// Get returns the job with the given ID, if it hasn't been swept yet.
+func (jq *JobQueue) Get(id string) (*Job, bool) {
+	jq.mu.RLock()
+	defer jq.mu.RUnlock()
+	job, ok := jq.jobs[id]
+	return job, ok
+}
+
+func (jq *JobQueue) worker() {
+	for {
+		select {
+		case job, ok := <-jq.tasks:
+			if !ok {
+				return
+			}
+			jq.run(job)
+		case <-jq.stopCh:
+			return
+		}
+	}
+}
+
+// run drives job through Converter.ConvertStream, translating its ConvertEvent
+// stream into Job progress/terminal state.
+func (jq *JobQueue) run(job *Job) {
+	start := time.Now()
+	job.setStatus(JobRunning)
+
+	ctx, cancel := context.WithTimeout(context.Background(), jq.converter.timeout)
+	defer cancel()
+
+	ch, err := jq.converter.ConvertStream(ctx, job.req, job.Format, job.ID)
+	if err != nil {
+		job.fail(err)
+		jq.logJobDone(job, start)
+		return
+	}
+
+	for evt := range ch {
+		switch evt.Type {
+		case EventCompleted:
+			job.succeed(evt.Result)
+		case EventFailed:
+			job.fail(evt.Err)
+		case EventWarning:
+			// Non-fatal diagnostic, not a stage transition - leave job.stage alone so
+			// JobView.Progress doesn't regress while a client is polling.
+		default:
+			job.applyEvent(evt)
+		}
+	}
+
+	jq.logJobDone(job, start)
+}
+
+func (jq *JobQueue) logJobDone(job *Job, start time.Time) {
+	view := job.View()
+	fields := map[string]interface{}{
+		"request_id":  job.RequestID,
+		"job_id":      job.ID,
+		"format":      job.Format,
+		"status":      view.Status,
+		"duration_ms": time.Since(start).Milliseconds(),
+	}
+	if view.Status == JobFailed {
+		jq.logger.Error("jobs", "Async conversion job failed", job.Err(), fields)
+		return
+	}
+	jq.logger.Info("jobs", "Async conversion job completed", fields)
+}
+
+// sweepLoop periodically removes terminal jobs (and their output files) once ttl has
+// elapsed since they last changed state - the temp-dir cleanup handleConvert used to
+// do synchronously in a defer now happens here, once the result has actually been
+// downloaded or has simply expired unclaimed.
+func (jq *JobQueue) sweepLoop() {
+	interval := jq.ttl / 4
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			jq.sweepExpired()
+		case <-jq.stopCh:
+			return
+		}
+	}
+}
+
+func (jq *JobQueue) sweepExpired() {
+	jq.mu.Lock()
+	var expired []*Job
+	for id, job := range jq.jobs {
+		if terminal, age := job.terminalAge(); terminal && age > jq.ttl {
+			expired = append(expired, job)
+			delete(jq.jobs, id)
+		}
+	}
+	jq.mu.Unlock()
+
+	for _, job := range expired {
+		_, result := job.resultAndStatus()
+		if result == nil {
+			continue
+		}
+		workDir := filepath.Dir(result.FilePath)
+		os.Remove(result.FilePath)
+		os.RemoveAll(workDir)
+	}
+
+	if len(expired) > 0 {
+		jq.logger.Info("jobs", "Swept expired async conversion jobs", map[string]interface{}{
+			"expired_count": len(expired),
+			"ttl":           jq.ttl.String(),
+		})
+	}
+}
+
+// Shutdown stops accepting new work and signals workers/the sweeper to exit. It does
+// not wait for in-flight jobs to finish - Server.Shutdown already runs under its own
+// ShutdownTimeout-bounded context via httpServer.Shutdown.
+func (jq *JobQueue) Shutdown() {
+	close(jq.stopCh)
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Pure-Go MOBI7/PalmDoc writer. Converter prefers this over shelling out to
+// Calibre's ebook-convert when cfg.NativeKindle is set (see convertViaEPUBUncached),
+// dropping the Calibre dependency for mobi/azw3 outputs entirely. Both formats are
+// written as the same MOBI7 container - this writer does not implement true KF8/AZW3
+// dual-container packaging, which Kindle readers still open as plain MOBI.
+const (
+	palmDocRecordSize = 4096
+	chapterHeadingRe  = `(?i)<h[12][^>]*>`
+)
+
+type kindleChapter struct {
+	Title string
+	HTML  string
+}
+
+// splitIntoChapters splits HTML into chapters on <h1>/<h2> boundaries, giving each
+// one its own NCX/TOC-style entry; content before the first heading becomes an
+// untitled front-matter chapter.
+func splitIntoChapters(html string) []kindleChapter {
+	re := regexp.MustCompile(chapterHeadingRe)
+	locs := re.FindAllStringIndex(html, -1)
+	if len(locs) == 0 {
+		return []kindleChapter{{HTML: html}}
+	}
+
+	var chapters []kindleChapter
+	if locs[0][0] > 0 {
+		chapters = append(chapters, kindleChapter{HTML: html[:locs[0][0]]})
+	}
+	for i, loc := range locs {
+		end := len(html)
+		if i+1 < len(locs) {
+			end = locs[i+1][0]
+		}
+		section := html[loc[0]:end]
+		chapters = append(chapters, kindleChapter{Title: extractHeadingText(section), HTML: section})
+	}
+	return chapters
+}
+
+func extractHeadingText(section string) string {
+	re := regexp.MustCompile(`(?is)<h[12][^>]*>(.*?)</h[12]>`)
+	match := re.FindStringSubmatch(section)
+	if len(match) < 2 {
+		return ""
+	}
+	return strings.TrimSpace(regexp.MustCompile(`<[^>]+>`).ReplaceAllString(match[1], ""))
+}
+
+// buildKindleCSS returns an @font-face stylesheet for cfg.KindleFontPath when the
+// bundled TTF is present on disk, so the generated MOBI/AZW3 honors the same
+// typography the Calibre/asciidoctor-epub3 reference pipelines apply.
+func buildKindleCSS(cfg Config) string {
+	if cfg.KindleFontPath == "" {
+		return ""
+	}
+	if _, err := os.Stat(cfg.KindleFontPath); err != nil {
+		return ""
+	}
+	return fmt.Sprintf(`@font-face { font-family: "KindleBody"; src: url("%s"); }
+body { font-family: "KindleBody", serif; }`, filepath.Base(cfg.KindleFontPath))
+}
+
+func (c *Converter) convertToKindleNativeUncached(ctx context.Context, req *ConvertRequest, kindleFormat string) (*ConvertResult, error) {
+	c.enrichMetadata(ctx, req)
+
+	workDir, err := os.MkdirTemp(c.tempDir, "kindle-native-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp work directory: %w", err)
+	}
+	// Only removed on error: on success, workDir is handed back inside the
+	// ConvertResult, and streamKindle's caller (a Job, whether from JobQueue.run or
+	// RegisterResult) is what actually owns its lifetime from here - see sweepExpired
+	// in jobs.go.
+	ok := false
+	defer func() {
+		if !ok {
+			os.RemoveAll(workDir)
+		}
+	}()
+
+	inputPath := filepath.Join(workDir, "input.adoc")
+	if err := os.WriteFile(inputPath, []byte(req.Content), FileModeFile); err != nil {
+		return nil, fmt.Errorf("failed to write content to temp file: %w", err)
+	}
+
+	htmlPath := filepath.Join(workDir, "body.html")
+	args := asciidoctorArgsFromAttributes(buildConversionAttributes(req))
+	args = append([]string{"-b", "html5", "-D", workDir, "-o", filepath.Base(htmlPath)}, args...)
+	args = append(args, filepath.Base(inputPath))
+
+	cmd := buildAsciidoctorCmd(ctx, c.asciidoctorPath, c.config, args)
+	cmd.Dir = workDir
+
+	startTime := time.Now()
+	output, err := cmd.CombinedOutput()
+	duration := time.Since(startTime)
+	if err != nil {
+		return nil, fmt.Errorf("html5 conversion for native %s failed: %w (output: %s)", kindleFormat, err, string(output))
+	}
+
+	bodyHTML, err := os.ReadFile(htmlPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read intermediate HTML: %w", err)
+	}
+
+	html := string(bodyHTML)
+	if css := buildKindleCSS(c.config); css != "" {
+		html = strings.Replace(html, "</head>", fmt.Sprintf("<style>%s</style></head>", css), 1)
+	}
+
+	chapters := splitIntoChapters(html)
+
+	var combined strings.Builder
+	for _, chapter := range chapters {
+		combined.WriteString(chapter.HTML)
+	}
+	text := []byte(combined.String())
+
+	var textRecords [][]byte
+	for len(text) > 0 {
+		end := palmDocRecordSize
+		if end > len(text) {
+			end = len(text)
+		}
+		textRecords = append(textRecords, text[:end])
+		text = text[end:]
+	}
+	if len(textRecords) == 0 {
+		textRecords = append(textRecords, []byte{})
+	}
+
+	var fontData []byte
+	if c.config.KindleFontPath != "" {
+		if data, readErr := os.ReadFile(c.config.KindleFontPath); readErr == nil {
+			fontData = data
+		}
+	}
+
+	authors := req.Authors
+	if len(authors) == 0 && req.Author != "" {
+		authors = []string{req.Author}
+	} else if len(authors) == 0 && req.Pubkey != "" {
+		authors = []string{encodePubkeyToNpub(req.Pubkey)}
+	}
+
+	title := req.Title
+	if title == "" {
+		title = "Document"
+	}
+
+	outputPath := filepath.Join(workDir, fmt.Sprintf("output.%s", kindleFormat))
+	if err := writePalmDoc(outputPath, title, strings.Join(authors, "; "), textRecords, fontData); err != nil {
+		return nil, fmt.Errorf("failed to write %s file: %w", kindleFormat, err)
+	}
+
+	info, err := os.Stat(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("%s output file not found: %w", kindleFormat, err)
+	}
+
+	c.logger.Info("converter", fmt.Sprintf("Native %s conversion completed", kindleFormat), map[string]interface{}{
+		"operation":   "conversion",
+		"backend":     "kindle-native",
+		"format":      kindleFormat,
+		"chapters":    len(chapters),
+		"output_size": info.Size(),
+		"duration_ms": duration.Milliseconds(),
+		"duration":    duration.String(),
+	})
+
+	result, err := c.finalizeConvertResult(outputPath, getMimeType(kindleFormat), info.Size())
+	if err != nil {
+		return nil, err
+	}
+	ok = true
+	return result, nil
+}
+
+// writePalmDoc packages textRecords into a PalmDOC/MOBI7 container: a PDB header,
+// uncompressed text records (compression type 1 - "none"; PalmDOC's LZ77 scheme is
+// not implemented here), a MOBI header record carrying EXTH metadata, and an
+// optional trailing font resource record.
+func writePalmDoc(outputPath, title, author string, textRecords [][]byte, fontData []byte) error {
+	var buf bytes.Buffer
+
+	mobiHeaderRecord := buildMobiHeaderRecord(title, author, len(textRecords))
+
+	totalRecords := 1 + len(textRecords)
+	if len(fontData) > 0 {
+		totalRecords++
+	}
+
+	name := title
+	if len(name) > 31 {
+		name = name[:31]
+	}
+
+	pdbHeader := make([]byte, 78)
+	copy(pdbHeader[0:32], name)
+	copy(pdbHeader[60:64], "BOOK")
+	copy(pdbHeader[64:68], "MOBI")
+	binary.BigEndian.PutUint16(pdbHeader[76:78], uint16(totalRecords))
+	buf.Write(pdbHeader)
+
+	recordListSize := totalRecords*8 + 2
+	offset := uint32(len(pdbHeader) + recordListSize)
+
+	offsets := make([]uint32, 0, totalRecords)
+	offsets = append(offsets, offset)
+	offset += uint32(len(mobiHeaderRecord))
+	for _, rec := range textRecords {
+		offsets = append(offsets, offset)
+		offset += uint32(len(rec))
+	}
+	if len(fontData) > 0 {
+		offsets = append(offsets, offset)
+	}
+
+	for i, off := range offsets {
+		recInfo := make([]byte, 8)
+		binary.BigEndian.PutUint32(recInfo[0:4], off)
+		recInfo[4] = byte(i)
+		buf.Write(recInfo)
+	}
+	buf.Write([]byte{0, 0}) // required 2-byte padding after the record info list
+
+	buf.Write(mobiHeaderRecord)
+	for _, rec := range textRecords {
+		buf.Write(rec)
+	}
+	if len(fontData) > 0 {
+		buf.Write(fontData)
+	}
+
+	return os.WriteFile(outputPath, buf.Bytes(), FileModeFile)
+}
+
+// buildMobiHeaderRecord builds record 0: the 16-byte PalmDOC header immediately
+// followed by the MOBI header and an EXTH metadata subheader.
+func buildMobiHeaderRecord(title, author string, numTextRecords int) []byte {
+	var buf bytes.Buffer
+
+	palmDoc := make([]byte, 16)
+	binary.BigEndian.PutUint16(palmDoc[0:2], 1) // compression: none
+	binary.BigEndian.PutUint16(palmDoc[8:10], uint16(numTextRecords))
+	binary.BigEndian.PutUint16(palmDoc[10:12], palmDocRecordSize)
+	buf.Write(palmDoc)
+
+	mobiHeader := make([]byte, 24)
+	copy(mobiHeader[0:4], "MOBI")
+	binary.BigEndian.PutUint32(mobiHeader[4:8], uint32(len(mobiHeader)))
+	binary.BigEndian.PutUint32(mobiHeader[8:12], 2)     // mobi type: book
+	binary.BigEndian.PutUint32(mobiHeader[12:16], 65001) // text encoding: UTF-8
+	binary.BigEndian.PutUint32(mobiHeader[20:24], 6)     // file format version
+	buf.Write(mobiHeader)
+
+	buf.Write(buildEXTHRecord(title, author))
+	buf.WriteString(title)
+
+	return buf.Bytes()
+}
+
+// buildEXTHRecord encodes the subset of EXTH metadata records readers rely on most:
+// 100 (author) and 503 (updated title).
+func buildEXTHRecord(title, author string) []byte {
+	type exthItem struct {
+		recType uint32
+		data    []byte
+	}
+	items := []exthItem{
+		{recType: 100, data: []byte(author)},
+		{recType: 503, data: []byte(title)},
+	}
+
+	var body bytes.Buffer
+	for _, item := range items {
+		header := make([]byte, 8)
+		binary.BigEndian.PutUint32(header[0:4], item.recType)
+		binary.BigEndian.PutUint32(header[4:8], uint32(8+len(item.data)))
+		body.Write(header)
+		body.Write(item.data)
+	}
+	for body.Len()%4 != 0 {
+		body.WriteByte(0)
+	}
+
+	var record bytes.Buffer
+	record.WriteString("EXTH")
+	lengthField := make([]byte, 4)
+	binary.BigEndian.PutUint32(lengthField, uint32(12+body.Len()))
+	record.Write(lengthField)
+	countField := make([]byte, 4)
+	binary.BigEndian.PutUint32(countField, uint32(len(items)))
+	record.Write(countField)
+	record.Write(body.Bytes())
+
+	return record.Bytes()
+}
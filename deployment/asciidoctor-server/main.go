@@ -1,12 +1,15 @@
 package main
 
 import (
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -31,7 +34,45 @@ const (
 	
 	// Temporary directory
 	DefaultTempDir = "/tmp"
-	
+
+	// Worker pool - 0 disables it and falls back to one-shot exec per request
+	DefaultWorkerPoolSize = 4
+
+	// Conversion backend
+	DefaultConverterBackend = "local"
+	DefaultDockerImage      = "asciidoctor/docker-asciidoctor:latest"
+
+	// Artifact storage backend
+	DefaultStorageBackend = "os"
+
+	// Conversion cache - disabled by default until operators opt in
+	DefaultConversionCacheEnabled = false
+	DefaultCacheMaxSizeMB         = int64(512)
+	DefaultCacheTTL               = 24 * time.Hour
+
+	// Image cache (see ImageCache in image_cache.go) - disabled by default
+	DefaultImageCacheEnabled   = false
+	DefaultImageCacheMaxSizeMB = int64(256)
+
+	// Image transcoding (see image_transcode.go) - these are the fallback bounds
+	// when a format's own entry in formatImageLimits doesn't apply; JPEG quality
+	// follows Calibre/Kindle Previewer's own default of 80.
+	DefaultImageMaxWidth    = 1600
+	DefaultImageMaxHeight   = 1600
+	DefaultImageJpegQuality = 80
+	DefaultImageGrayscale   = false
+
+	// EPUB backend - "ruby" (asciidoctor-epub3) unless operators opt into "native"
+	DefaultEPUBBackend = "ruby"
+
+	// Book metadata enrichment (see MetadataEnricher in metadata_enrich.go)
+	DefaultMetadataProvider = "google_books"
+	DefaultMetadataCacheTTL = 24 * time.Hour
+
+	// Native Kindle (MOBI/AZW3) generation - off by default, falls back to Calibre
+	DefaultNativeKindle   = false
+	DefaultKindleFontPath = "/app/deployment/assets/fonts/serif.ttf"
+
 	// File permissions
 	FileModeDir  = 0755
 	FileModeFile = 0644
@@ -45,6 +86,41 @@ const (
 	
 	// Content size limits
 	MaxContentSize = 50 * 1024 * 1024 // 50MB
+
+	// MaxMultipartMemory bounds how much of a multipart /convert/{format} request
+	// (see parseMultipartConvertRequest in handlers.go) is buffered in memory before
+	// spilling uploaded parts to temp files - mirrors net/http's own 32MB default.
+	MaxMultipartMemory = 32 * 1024 * 1024
+
+	// Async job queue (see JobQueue in jobs.go)
+	DefaultJobWorkers   = 4
+	DefaultJobQueueSize = 64
+	DefaultJobTTL       = 1 * time.Hour
+
+	// gzip response compression (see compressionMiddleware in middleware.go)
+	DefaultGzipLevel    = gzip.DefaultCompression
+	DefaultGzipMinBytes = 1024
+	DefaultGzipTypes    = "text/,application/json,application/javascript"
+
+	// HTTP access log (see accessLogMiddleware in accesslog.go) - disabled by default,
+	// opt in by setting ASCIIDOCTOR_HTTP_LOG_PATH
+	DefaultHTTPLogMaxBody    = 64 * 1024
+	DefaultHTTPLogMaxSizeMB  = 100
+	DefaultHTTPLogMaxBackups = 7
+	DefaultHTTPLogMaxAgeDays = 28
+	DefaultHTTPLogGzip       = true
+
+	// Retry (see RetryConfig/withRetry in retry.go) - disabled by default
+	DefaultRetryEnabled         = false
+	DefaultRetryInitialInterval = 500 * time.Millisecond
+	DefaultRetryMaxInterval     = 10 * time.Second
+	DefaultRetryMaxElapsedTime  = 1 * time.Minute
+	DefaultRetryMultiplier      = 2.0
+
+	// Auth (see AuthMode/authMiddleware in auth.go) - disabled unless
+	// ASCIIDOCTOR_API_TOKENS, ASCIIDOCTOR_JWT_JWKS_URL or ASCIIDOCTOR_JWT_HMAC_SECRET
+	// is set.
+	DefaultJWTJWKSRefresh = 15 * time.Minute
 )
 
 type Server struct {
@@ -52,6 +128,42 @@ type Server struct {
 	httpServer *http.Server
 	logger     *Logger
 	converter  *Converter
+	jobs       *JobQueue
+
+	gzipLevel    int
+	gzipMinBytes int
+	gzipTypes    []string
+	gzipPool     sync.Pool
+
+	// accessLog is nil unless ASCIIDOCTOR_HTTP_LOG_PATH is configured - see
+	// accessLogMiddleware in accesslog.go.
+	accessLog        io.Writer
+	accessLogMaxBody int
+
+	retryConfig RetryConfig
+
+	// metrics is nil unless ASCIIDOCTOR_METRICS_ENABLED is set - see metrics.go.
+	// metricsServer is only set when ASCIIDOCTOR_METRICS_BIND gives /metrics its own
+	// listener instead of being dispatched to from Start's main handler.
+	metrics       *Metrics
+	metricsBind   string
+	metricsServer *http.Server
+
+	// Auth (see AuthMode/authMiddleware in auth.go) - authMode is "none" unless
+	// apiTokens or a JWT secret/JWKS is configured.
+	authMode      AuthMode
+	apiTokens     []string
+	jwtHMACSecret []byte
+	jwtIssuer     string
+	jwtAudience   string
+	jwks          *jwksCache
+
+	// Image transcoding defaults (see image_transcode.go) - per-request query
+	// params can override these for one conversion.
+	imageMaxWidth    int
+	imageMaxHeight   int
+	imageJpegQuality int
+	imageGrayscale   bool
 }
 
 func main() {
@@ -73,8 +185,17 @@ func main() {
 		"conversion_timeout": config.ConversionTimeout.String(),
 	})
 
-	// Initialize converter with full config
-	converter, err := NewConverter(logger, config)
+	// Initialize converter with full config. ConverterBackend "embedded" gets its own
+	// constructor rather than going through resolveBackend inside NewConverter, since
+	// NewConverter still requires a host asciidoctor up front - defeating the point of
+	// the embedded runtime, which is running without one.
+	var converter *Converter
+	var err error
+	if config.ConverterBackend == "embedded" {
+		converter, err = NewConverterEmbedded(logger, config)
+	} else {
+		converter, err = NewConverter(logger, config)
+	}
 	if err != nil {
 		logger.Error("asciidoctor-server", "Failed to initialize converter", err, map[string]interface{}{
 			"error_type": "initialization_error",
@@ -139,32 +260,100 @@ func loadConfig() (cfg *Config) {
 func NewServer(config *Config, logger *Logger, converter *Converter) *Server {
 	// Config is now the full config from config.go
 	router := mux.NewRouter()
-	
+
+	var metrics *Metrics
+	if config.MetricsEnabled {
+		metrics = NewMetrics()
+	}
+
+	// authMode is derived from which auth env vars are set, not chosen directly -
+	// JWT takes precedence since a deployment configuring both is almost certainly
+	// mid-migration from bearer tokens to JWT.
+	authMode := AuthModeNone
+	var jwks *jwksCache
+	if config.JWTJWKSURL != "" || len(config.JWTHMACSecret) > 0 {
+		authMode = AuthModeJWT
+		if config.JWTJWKSURL != "" {
+			jwks = newJWKSCache(config.JWTJWKSURL, config.JWTJWKSRefresh)
+		}
+	} else if len(config.APITokens) > 0 {
+		authMode = AuthModeBearer
+	}
+
 	server := &Server{
-		router:    router,
-		logger:    logger,
-		converter: converter,
-		httpServer: &http.Server{
-			Addr:         fmt.Sprintf("%s:%s", config.Host, config.Port),
-			Handler:      router,
-			ReadTimeout:  DefaultReadTimeout,
-			WriteTimeout: DefaultWriteTimeout,
-			IdleTimeout:  DefaultIdleTimeout,
-		},
+		router:           router,
+		logger:           logger,
+		converter:        converter,
+		jobs:             NewJobQueue(logger, converter, config.JobWorkers, config.JobQueueSize, config.JobTTL),
+		gzipLevel:        config.GzipLevel,
+		gzipMinBytes:     config.GzipMinBytes,
+		gzipTypes:        config.GzipTypes,
+		accessLog:        newAccessLogWriter(config),
+		accessLogMaxBody: config.HTTPLogMaxBody,
+		retryConfig:      config.Retry,
+		metrics:          metrics,
+		metricsBind:      config.MetricsBind,
+		authMode:         authMode,
+		apiTokens:        config.APITokens,
+		jwtHMACSecret:    config.JWTHMACSecret,
+		jwtIssuer:        config.JWTIssuer,
+		jwtAudience:      config.JWTAudience,
+		jwks:             jwks,
+		imageMaxWidth:    config.ImageMaxWidth,
+		imageMaxHeight:   config.ImageMaxHeight,
+		imageJpegQuality: config.ImageJpegQuality,
+		imageGrayscale:   config.ImageGrayscale,
+	}
+	server.gzipPool.New = func() interface{} {
+		gz, _ := gzip.NewWriterLevel(io.Discard, server.gzipLevel)
+		return gz
 	}
 
 	// Setup routes
 	server.setupRoutes(config.AllowOrigin)
 
+	// /metrics is dispatched to ahead of s.router so it never runs through
+	// compressionMiddleware/loggingMiddleware/corsMiddleware - unless MetricsBind
+	// gives it a listener of its own (see Start), in which case it's absent here.
+	server.httpServer = &http.Server{
+		Addr:         fmt.Sprintf("%s:%s", config.Host, config.Port),
+		Handler:      server.rootHandler(),
+		ReadTimeout:  DefaultReadTimeout,
+		WriteTimeout: DefaultWriteTimeout,
+		IdleTimeout:  DefaultIdleTimeout,
+	}
+
 	return server
 }
 
+// rootHandler wraps s.router so that an enabled /metrics endpoint bypasses the
+// router's middleware chain entirely, unless it's been split off onto its own
+// listener via ASCIIDOCTOR_METRICS_BIND. It still runs through authMiddleware
+// when auth is enabled - metrics expose request volumes and error rates, which
+// is exactly the kind of thing an operator turning on auth wants gated too.
+func (s *Server) rootHandler() http.Handler {
+	if s.metrics == nil || s.metricsBind != "" {
+		return s.router
+	}
+
+	metricsHandler := s.authMiddleware(s.metrics.Handler())
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/metrics" {
+			metricsHandler.ServeHTTP(w, r)
+			return
+		}
+		s.router.ServeHTTP(w, r)
+	})
+}
+
 func (s *Server) setupRoutes(allowOrigin string) {
 	// Middleware - OPTIMIZED: Compression first for better performance
 	s.router.Use(s.compressionMiddleware)
 	s.router.Use(s.loggingMiddleware)
+	s.router.Use(s.accessLogMiddleware)
 	s.router.Use(s.corsMiddleware(allowOrigin))
 	s.router.Use(s.recoveryMiddleware)
+	s.router.Use(s.authMiddleware)
 
 	// Health check
 	s.router.HandleFunc("/healthz", s.handleHealth).Methods("GET")
@@ -174,22 +363,64 @@ func (s *Server) setupRoutes(allowOrigin string) {
 	
 	// Conversion endpoints
 	s.router.HandleFunc("/convert/epub", s.handleConvertEPUB).Methods("POST")
+	s.router.HandleFunc("/convert/epub/native", s.handleConvertEPUBNative).Methods("POST")
 	s.router.HandleFunc("/convert/pdf", s.handleConvertPDF).Methods("POST")
 	s.router.HandleFunc("/convert/html5", s.handleConvertHTML5).Methods("POST")
 	s.router.HandleFunc("/convert/mobi", s.handleConvertMOBI).Methods("POST")
 	s.router.HandleFunc("/convert/azw3", s.handleConvertAZW3).Methods("POST")
 	s.router.HandleFunc("/convert/docbook5", s.handleConvertDocBook5).Methods("POST")
-	
+
+	// Metadata extraction (title, authors, revision, TOC) without rendering an artifact
+	s.router.HandleFunc("/metadata", s.handleMetadata).Methods("POST")
+
+	// Multi-format fan-out - runs several output formats concurrently against one request
+	s.router.HandleFunc("/convert/all", s.handleConvertAll).Methods("POST")
+
+	// Async conversion jobs - poll instead of holding one HTTP connection open for the
+	// whole ASCIIDOCTOR_CONVERSION_TIMEOUT window (see JobQueue in jobs.go)
+	s.router.HandleFunc("/jobs/{format}", s.handleSubmitJob).Methods("POST")
+	s.router.HandleFunc("/jobs/{id}/result", s.handleJobResult).Methods("GET")
+	s.router.HandleFunc("/jobs/{id}", s.handleJobStatus).Methods("GET")
+
 	// Root endpoint
 	s.router.HandleFunc("/", s.handleRoot).Methods("GET")
 }
 
 func (s *Server) Start() error {
+	if s.metrics != nil && s.metricsBind != "" {
+		s.metricsServer = &http.Server{
+			Addr:    s.metricsBind,
+			Handler: s.metrics.Handler(),
+		}
+		go func() {
+			if err := s.metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				s.logger.Error("metrics", "Metrics listener failed", err, map[string]interface{}{
+					"error_type": "server_error",
+					"component":  "metrics_server",
+					"bind":       s.metricsBind,
+				})
+			}
+		}()
+	}
 	return s.httpServer.ListenAndServe()
 }
 
 func (s *Server) Shutdown(ctx context.Context) error {
-	return s.httpServer.Shutdown(ctx)
+	// Drain in-flight requests before tearing down the pool and job queue they
+	// depend on - closing those out from under a live conversion turns a 1-5
+	// minute job's finish line into a panic (worker pool) or a hang (job queue).
+	err := s.httpServer.Shutdown(ctx)
+	if s.converter.pool != nil {
+		s.converter.pool.Shutdown()
+	}
+	s.jobs.Shutdown()
+	if closer, ok := s.accessLog.(io.Closer); ok {
+		closer.Close()
+	}
+	if s.metricsServer != nil {
+		s.metricsServer.Shutdown(ctx)
+	}
+	return err
 }
 
 func (s *Server) handleRoot(w http.ResponseWriter, r *http.Request) {
@@ -204,11 +435,12 @@ func (s *Server) handleRoot(w http.ResponseWriter, r *http.Request) {
 			"pdf":     "/convert/pdf",
 			"mobi":    "/convert/mobi",
 			"azw3":    "/convert/azw3",
+			"metadata": "/metadata",
 			"health":  "/healthz",
 			"api_docs": "/api",
 		},
 	}
-	
+
 	s.writeJSON(w, http.StatusOK, response)
 }
 
@@ -232,12 +464,25 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 			"pdf":    "/convert/pdf",
 			"mobi":   "/convert/mobi",
 			"azw3":   "/convert/azw3",
+			"metadata": "/metadata",
 		},
 		"port":     s.httpServer.Addr,
 		"converter_ready": converterReady,
 		"timestamp": time.Now().UTC().Format(time.RFC3339),
 	}
-	
+
+	if s.converter.pool != nil {
+		response["worker_pool"] = s.converter.pool.Stats()
+	}
+
+	if s.converter.imageCache != nil {
+		response["image_cache"] = s.converter.imageCache.Stats()
+	}
+
+	if s.converter.cache != nil {
+		response["conversion_cache"] = s.converter.cache.Stats()
+	}
+
 	s.writeJSON(w, statusCode, response)
 }
 
@@ -256,7 +501,7 @@ func (s *Server) handleAPI(w http.ResponseWriter, r *http.Request) {
 			"convert_epub": map[string]interface{}{
 				"method":      "POST",
 				"path":        "/convert/epub",
-				"description": "Convert AsciiDoc content to EPUB",
+				"description": "Convert AsciiDoc content to EPUB. Accepts application/json or, for bundling local images/assets without public URLs, multipart/form-data with a 'source' part plus 'images'/'asset' parts",
 			},
 			"convert_html5": map[string]interface{}{
 				"method":      "POST",
@@ -278,16 +523,59 @@ func (s *Server) handleAPI(w http.ResponseWriter, r *http.Request) {
 				"path":        "/convert/azw3",
 				"description": "Convert AsciiDoc content to AZW3 (Kindle Format 8, via EPUB)",
 			},
+			"metadata": map[string]interface{}{
+				"method":      "POST",
+				"path":        "/metadata",
+				"description": "Extract structured document metadata (title, authors, revision, TOC) without rendering an artifact",
+			},
+			"convert_all": map[string]interface{}{
+				"method":      "POST",
+				"path":        "/convert/all",
+				"description": "Convert to several formats concurrently in one request; artifacts are returned base64-encoded",
+			},
+			"convert_stream": map[string]interface{}{
+				"method":      "POST",
+				"path":        "/convert/{format}?stream=sse",
+				"description": "Same as /convert/{format}, but responds with text/event-stream progress (stage/image_downloaded/warning/done events) ending in a result event with a one-shot /jobs/{id}/result download URL",
+			},
+			"submit_job": map[string]interface{}{
+				"method":      "POST",
+				"path":        "/jobs/{format}",
+				"description": "Enqueue an async conversion (epub/html5/pdf/mobi/azw3/docbook5) and return 202 with a job_id",
+			},
+			"job_status": map[string]interface{}{
+				"method":      "GET",
+				"path":        "/jobs/{id}",
+				"description": "Poll an async conversion job's status and progress",
+			},
+			"job_result": map[string]interface{}{
+				"method":      "GET",
+				"path":        "/jobs/{id}/result",
+				"description": "Download the finished artifact for a succeeded job",
+			},
 		},
 	}
-	
+
+	if s.metrics != nil {
+		endpoint := map[string]interface{}{
+			"method":      "GET",
+			"path":        "/metrics",
+			"description": "Prometheus metrics for the conversion pipeline",
+		}
+		if s.metricsBind != "" {
+			endpoint["path"] = fmt.Sprintf("%s/metrics", s.metricsBind)
+			endpoint["description"] = "Prometheus metrics for the conversion pipeline (served on its own listener, see ASCIIDOCTOR_METRICS_BIND)"
+		}
+		response["endpoints"].(map[string]interface{})["metrics"] = endpoint
+	}
+
 	s.writeJSON(w, http.StatusOK, response)
 }
 
 func (s *Server) writeJSON(w http.ResponseWriter, statusCode int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
-	
+
 	if err := json.NewEncoder(w).Encode(data); err != nil {
 		s.logger.Error("http", "Failed to encode JSON response", err, map[string]interface{}{
 			"error_type": "encoding_error",
@@ -295,3 +583,14 @@ func (s *Server) writeJSON(w http.ResponseWriter, statusCode int, data interface
 		})
 	}
 }
+
+// writeSSE writes one Server-Sent Events frame: an "event:" line naming it, a single
+// JSON-encoded "data:" line, and the blank line terminating the frame. The caller is
+// responsible for flushing afterwards - see handleConvertSSE in handlers.go.
+func writeSSE(w http.ResponseWriter, event string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DocumentMetadata is the structured metadata asciidoctor resolves for a document -
+// the same title/authors/revision/description it embeds in a DocBook <info> block,
+// plus the section outline. Useful for Nostr event tagging and library indexing
+// without paying for a full PDF/EPUB render.
+type DocumentMetadata struct {
+	Title       string    `json:"title"`
+	Subtitle    string    `json:"subtitle,omitempty"`
+	Authors     []Author  `json:"authors,omitempty"`
+	Revision    Revision  `json:"revision"`
+	Description string    `json:"description,omitempty"`
+	Summary     string    `json:"summary,omitempty"`
+	Keywords    []string  `json:"keywords,omitempty"`
+	TOC         []Section `json:"toc,omitempty"`
+}
+
+// Author is one resolved author entry. Npub is only populated when Name itself is a
+// hex pubkey or npub - ExtractMetadata has no ConvertRequest.Pubkey to fall back on.
+type Author struct {
+	Name  string `json:"name"`
+	Email string `json:"email,omitempty"`
+	Npub  string `json:"npub,omitempty"`
+}
+
+// Revision mirrors DocBook's <revhistory><revision>.
+type Revision struct {
+	Number string `json:"number,omitempty"`
+	Date   string `json:"date,omitempty"`
+	Remark string `json:"remark,omitempty"`
+}
+
+// Section is one node of the resolved table of contents.
+type Section struct {
+	Level    int       `json:"level"`
+	Title    string    `json:"title"`
+	ID       string    `json:"id,omitempty"`
+	Children []Section `json:"children,omitempty"`
+}
+
+// docbookDocument and friends mirror just enough of the DocBook 5 schema to recover
+// DocumentMetadata; encoding/xml's ",any" catch-all lets docbookNode recurse into
+// chapter/section/simplesect without needing a field per element name.
+type docbookDocument struct {
+	XMLName xml.Name
+	Info    docbookInfo  `xml:"info"`
+	Nodes   []docbookNode `xml:",any"`
+}
+
+type docbookInfo struct {
+	Title     string            `xml:"title"`
+	Subtitle  string            `xml:"subtitle"`
+	Authors   []docbookAuthor   `xml:"author"`
+	Revisions []docbookRevision `xml:"revhistory>revision"`
+	Abstract  string            `xml:"abstract>simpara"`
+	Keywords  []string          `xml:"keywordset>keyword"`
+}
+
+type docbookAuthor struct {
+	PersonName struct {
+		FirstName string `xml:"firstname"`
+		SurName   string `xml:"surname"`
+	} `xml:"personname"`
+	FirstName string `xml:"firstname"`
+	SurName   string `xml:"surname"`
+	OrgName   string `xml:"orgname"`
+	Email     string `xml:"email"`
+}
+
+func (a docbookAuthor) name() string {
+	if full := strings.TrimSpace(a.PersonName.FirstName + " " + a.PersonName.SurName); full != "" {
+		return full
+	}
+	if full := strings.TrimSpace(a.FirstName + " " + a.SurName); full != "" {
+		return full
+	}
+	return a.OrgName
+}
+
+type docbookRevision struct {
+	Number string `xml:"revnumber"`
+	Date   string `xml:"date"`
+	Remark string `xml:"revremark"`
+}
+
+// docbookNode is a generic chapter/section/simplesect node; Nodes recurses into
+// whatever children it has, and ExtractMetadata filters to section-like elements
+// when building the TOC.
+type docbookNode struct {
+	XMLName xml.Name
+	ID      string        `xml:"id,attr"`
+	Title   string        `xml:"title"`
+	Nodes   []docbookNode `xml:",any"`
+}
+
+var sectionElements = map[string]bool{
+	"chapter":    true,
+	"section":    true,
+	"simplesect": true,
+	"sect1":      true,
+	"sect2":      true,
+	"sect3":      true,
+	"appendix":   true,
+	"preface":    true,
+}
+
+func (n docbookNode) toSection(level int) (Section, bool) {
+	if !sectionElements[n.XMLName.Local] || strings.TrimSpace(n.Title) == "" {
+		return Section{}, false
+	}
+
+	section := Section{Level: level, Title: n.Title, ID: n.ID}
+	for _, child := range n.Nodes {
+		if childSection, ok := child.toSection(level + 1); ok {
+			section.Children = append(section.Children, childSection)
+		}
+	}
+	return section, true
+}
+
+// ExtractMetadata runs asciidoctor with the docbook5 backend against content and
+// parses the resulting <info>/<section> tree into a DocumentMetadata. content is
+// expected to already carry a document header (e.g. via ValidateAndFixAsciiDoc) -
+// ExtractMetadata reads back whatever metadata asciidoctor itself resolved, it
+// doesn't inject any of its own.
+func (c *Converter) ExtractMetadata(ctx context.Context, content string) (*DocumentMetadata, error) {
+	workDir, err := os.MkdirTemp(c.tempDir, "metadata-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp work directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	inputPath := filepath.Join(workDir, "input.adoc")
+	if err := os.WriteFile(inputPath, []byte(content), FileModeFile); err != nil {
+		return nil, fmt.Errorf("failed to write content to temp file: %w", err)
+	}
+
+	conversionCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	outputPath := filepath.Join(workDir, "output.xml")
+	cmd := buildAsciidoctorCmd(conversionCtx, c.asciidoctorPath, c.config, []string{
+		"-b", "docbook5",
+		"-D", workDir,
+		"-o", filepath.Base(outputPath),
+		filepath.Base(inputPath),
+	})
+	cmd.Dir = workDir
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("docbook5 conversion for metadata extraction failed: %w (output: %s)", err, string(output))
+	}
+
+	xmlBytes, err := os.ReadFile(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read docbook output: %w", err)
+	}
+
+	var doc docbookDocument
+	if err := xml.Unmarshal(xmlBytes, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse docbook output: %w", err)
+	}
+
+	metadata := &DocumentMetadata{
+		Title:       doc.Info.Title,
+		Subtitle:    doc.Info.Subtitle,
+		Description: doc.Info.Abstract,
+		Summary:     doc.Info.Abstract,
+		Keywords:    doc.Info.Keywords,
+	}
+
+	for _, a := range doc.Info.Authors {
+		author := Author{Name: a.name(), Email: a.Email}
+		if isHexPubkey(author.Name) {
+			author.Npub = encodePubkeyToNpub(author.Name)
+		} else if strings.HasPrefix(author.Name, "npub1") {
+			author.Npub = author.Name
+		}
+		metadata.Authors = append(metadata.Authors, author)
+	}
+
+	if len(doc.Info.Revisions) > 0 {
+		rev := doc.Info.Revisions[0]
+		metadata.Revision = Revision{Number: rev.Number, Date: rev.Date, Remark: rev.Remark}
+	}
+
+	for _, node := range doc.Nodes {
+		if section, ok := node.toSection(1); ok {
+			metadata.TOC = append(metadata.TOC, section)
+		}
+	}
+
+	return metadata, nil
+}
+
+// attachMetadataIfRequested extracts metadata alongside an already-produced
+// conversion result when the caller asked for it, so a single request can yield both
+// the artifact and its parsed outline. Extraction failures never fail the conversion
+// itself - the artifact was already produced successfully.
+func (c *Converter) attachMetadataIfRequested(ctx context.Context, req *ConvertRequest, result *ConvertResult) *ConvertResult {
+	if result == nil || !req.IncludeMetadata {
+		return result
+	}
+
+	metadata, err := c.ExtractMetadata(ctx, req.Content)
+	if err != nil {
+		c.logger.Warn("converter", "Failed to extract metadata for conversion result", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return result
+	}
+
+	result.Metadata = metadata
+	return result
+}
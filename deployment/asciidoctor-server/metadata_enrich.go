@@ -0,0 +1,299 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BookMetadata is what a MetadataProvider resolves for an ISBN or Google Books
+// volume ID - just enough to fill in the AsciiDoc header attributes a bare wiki
+// article about a book usually doesn't have hand-copied in.
+type BookMetadata struct {
+	Title         string
+	Authors       []string
+	Publisher     string
+	PublishedDate string
+	Description   string
+	CoverImageURL string
+}
+
+// MetadataProvider resolves BookMetadata from an ISBN or a Google Books volume ID.
+// GoogleBooksProvider is the only implementation today; the interface exists so an
+// OpenLibrary-backed provider or a local catalog lookup can be swapped in via
+// Config.MetadataProvider without MetadataEnricher itself changing.
+type MetadataProvider interface {
+	Lookup(ctx context.Context, isbn, googleBooksID string) (*BookMetadata, error)
+}
+
+var (
+	isbnAttrRe          = regexp.MustCompile(`(?m)^:isbn:[ \t]*(.+?)[ \t]*$`)
+	googleBooksIDAttrRe = regexp.MustCompile(`(?m)^:google-books-id:[ \t]*(.+?)[ \t]*$`)
+)
+
+// firstSubmatch returns the first capture group re matches in content, or "".
+func firstSubmatch(re *regexp.Regexp, content string) string {
+	match := re.FindStringSubmatch(content)
+	if len(match) < 2 {
+		return ""
+	}
+	return match[1]
+}
+
+// attrAlreadySet reports whether content's header already defines attr with a
+// non-empty value, so MetadataEnricher never overwrites a value the author set
+// themselves.
+func attrAlreadySet(content, attr string) bool {
+	re := regexp.MustCompile(fmt.Sprintf(`(?m)^:%s:[ \t]*\S`, regexp.QuoteMeta(attr)))
+	return re.MatchString(content)
+}
+
+// insertAfterMatch inserts lines immediately after the line re matches in content -
+// used to place injected attributes right after the :isbn:/:google-books-id: line
+// that triggered the lookup, rather than needing to know where the header ends.
+func insertAfterMatch(content string, re *regexp.Regexp, lines []string) string {
+	loc := re.FindStringIndex(content)
+	if loc == nil {
+		return content
+	}
+
+	insertAt := loc[1]
+	if insertAt < len(content) && content[insertAt] == '\n' {
+		insertAt++
+	}
+
+	return content[:insertAt] + strings.Join(lines, "\n") + "\n" + content[insertAt:]
+}
+
+// metadataCacheEntry is one cached provider lookup, expired MetadataCacheTTL after
+// it was stored.
+type metadataCacheEntry struct {
+	meta      *BookMetadata
+	expiresAt time.Time
+}
+
+// MetadataEnricher looks up title/authors/publisher/description/cover art for
+// AsciiDoc content that declares an :isbn: or :google-books-id: attribute, and
+// injects whatever the author hasn't already set into the header before EPUB/MOBI/
+// AZW3 generation - see Converter.enrichMetadata. Lookups are cached by identifier
+// so repeat conversions of the same book don't re-hit the provider on every request.
+type MetadataEnricher struct {
+	logger   *Logger
+	provider MetadataProvider
+	ttl      time.Duration
+
+	mu    sync.Mutex
+	cache map[string]metadataCacheEntry
+}
+
+// NewMetadataEnricher builds an enricher for cfg.MetadataProvider. "google_books"
+// (the default) is the only provider understood today; an unrecognized name disables
+// enrichment entirely rather than failing startup - Enrich then becomes a no-op.
+func NewMetadataEnricher(logger *Logger, cfg Config) *MetadataEnricher {
+	var provider MetadataProvider
+	switch cfg.MetadataProvider {
+	case "", "google_books":
+		provider = NewGoogleBooksProvider(cfg.GoogleBooksAPIKey)
+	default:
+		logger.Warn("metadata_enrich", "Unknown metadata provider, book metadata enrichment disabled", map[string]interface{}{
+			"provider": cfg.MetadataProvider,
+		})
+	}
+
+	return &MetadataEnricher{
+		logger:   logger,
+		provider: provider,
+		ttl:      cfg.MetadataCacheTTL,
+		cache:    make(map[string]metadataCacheEntry),
+	}
+}
+
+// Enrich injects :author:/:revdate:/:description:/:front-cover-image: into content's
+// header from the MetadataProvider lookup for its :isbn:/:google-books-id:
+// attribute, for whichever of those the author hasn't already set. The injected
+// :front-cover-image: is just the provider's cover URL - it flows through the normal
+// ImageHandler/ImageCache download path the same as any other cover image, rather
+// than being fetched here. Enrich never fails the conversion: it returns content
+// unchanged when there's no provider, neither attribute is present, or the lookup
+// errors.
+func (me *MetadataEnricher) Enrich(ctx context.Context, content string) string {
+	if me == nil || me.provider == nil {
+		return content
+	}
+
+	isbn := firstSubmatch(isbnAttrRe, content)
+	googleBooksID := firstSubmatch(googleBooksIDAttrRe, content)
+	if isbn == "" && googleBooksID == "" {
+		return content
+	}
+
+	meta, err := me.lookup(ctx, isbn, googleBooksID)
+	if err != nil {
+		me.logger.Warn("metadata_enrich", "Book metadata lookup failed, leaving header as-is", map[string]interface{}{
+			"isbn":            isbn,
+			"google_books_id": googleBooksID,
+			"error":           err.Error(),
+		})
+		return content
+	}
+
+	var toInject []string
+	if !attrAlreadySet(content, "author") && len(meta.Authors) > 0 {
+		toInject = append(toInject, fmt.Sprintf(":author: %s", strings.Join(meta.Authors, "; ")))
+	}
+	if !attrAlreadySet(content, "revdate") && meta.PublishedDate != "" {
+		toInject = append(toInject, fmt.Sprintf(":revdate: %s", meta.PublishedDate))
+	}
+	if !attrAlreadySet(content, "description") && meta.Description != "" {
+		toInject = append(toInject, fmt.Sprintf(":description: %s", meta.Description))
+	}
+	if !attrAlreadySet(content, "front-cover-image") && meta.CoverImageURL != "" {
+		toInject = append(toInject, fmt.Sprintf(":front-cover-image: %s", meta.CoverImageURL))
+	}
+	if len(toInject) == 0 {
+		return content
+	}
+
+	me.logger.Info("metadata_enrich", "Injecting book metadata into AsciiDoc header", map[string]interface{}{
+		"isbn":            isbn,
+		"google_books_id": googleBooksID,
+		"injected":        toInject,
+	})
+
+	insertRe := isbnAttrRe
+	if isbn == "" {
+		insertRe = googleBooksIDAttrRe
+	}
+	return insertAfterMatch(content, insertRe, toInject)
+}
+
+// lookup consults the cache before calling through to me.provider, keyed by ISBN
+// (falling back to the Google Books volume ID when there's no ISBN).
+func (me *MetadataEnricher) lookup(ctx context.Context, isbn, googleBooksID string) (*BookMetadata, error) {
+	key := isbn
+	if key == "" {
+		key = "gbid:" + googleBooksID
+	}
+
+	me.mu.Lock()
+	if entry, ok := me.cache[key]; ok && time.Now().Before(entry.expiresAt) {
+		me.mu.Unlock()
+		return entry.meta, nil
+	}
+	me.mu.Unlock()
+
+	meta, err := me.provider.Lookup(ctx, isbn, googleBooksID)
+	if err != nil {
+		return nil, err
+	}
+
+	me.mu.Lock()
+	me.cache[key] = metadataCacheEntry{meta: meta, expiresAt: time.Now().Add(me.ttl)}
+	me.mu.Unlock()
+
+	return meta, nil
+}
+
+// googleBooksAPIBase is the Google Books volumes API - see
+// https://developers.google.com/books/docs/v1/using#st_params.
+const googleBooksAPIBase = "https://www.googleapis.com/books/v1/volumes"
+
+// GoogleBooksProvider resolves BookMetadata via the Google Books volumes API.
+// apiKey is optional - Google Books serves unauthenticated requests too, just at a
+// lower rate limit.
+type GoogleBooksProvider struct {
+	apiKey string
+	client *http.Client
+}
+
+func NewGoogleBooksProvider(apiKey string) *GoogleBooksProvider {
+	return &GoogleBooksProvider{
+		apiKey: apiKey,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type googleBooksVolume struct {
+	VolumeInfo struct {
+		Title         string   `json:"title"`
+		Authors       []string `json:"authors"`
+		Publisher     string   `json:"publisher"`
+		PublishedDate string   `json:"publishedDate"`
+		Description   string   `json:"description"`
+		ImageLinks    struct {
+			Thumbnail string `json:"thumbnail"`
+		} `json:"imageLinks"`
+	} `json:"volumeInfo"`
+}
+
+type googleBooksSearchResponse struct {
+	Items []googleBooksVolume `json:"items"`
+}
+
+func (p *GoogleBooksProvider) Lookup(ctx context.Context, isbn, googleBooksID string) (*BookMetadata, error) {
+	var volume googleBooksVolume
+	if googleBooksID != "" {
+		if err := p.fetch(ctx, fmt.Sprintf("%s/%s", googleBooksAPIBase, url.PathEscape(googleBooksID)), &volume); err != nil {
+			return nil, err
+		}
+	} else {
+		var resp googleBooksSearchResponse
+		q := url.Values{}
+		q.Set("q", "isbn:"+isbn)
+		if err := p.fetch(ctx, googleBooksAPIBase+"?"+q.Encode(), &resp); err != nil {
+			return nil, err
+		}
+		if len(resp.Items) == 0 {
+			return nil, fmt.Errorf("no Google Books volume found for ISBN %s", isbn)
+		}
+		volume = resp.Items[0]
+	}
+
+	info := volume.VolumeInfo
+	return &BookMetadata{
+		Title:         info.Title,
+		Authors:       info.Authors,
+		Publisher:     info.Publisher,
+		PublishedDate: info.PublishedDate,
+		Description:   info.Description,
+		CoverImageURL: strings.Replace(info.ImageLinks.Thumbnail, "http://", "https://", 1),
+	}, nil
+}
+
+func (p *GoogleBooksProvider) fetch(ctx context.Context, rawURL string, out interface{}) error {
+	if p.apiKey != "" {
+		sep := "?"
+		if strings.Contains(rawURL, "?") {
+			sep = "&"
+		}
+		rawURL += sep + "key=" + url.QueryEscape(p.apiKey)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return fmt.Errorf("building Google Books request: %w", err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Google Books request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("Google Books API returned %d: %s", resp.StatusCode, string(body))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding Google Books response: %w", err)
+	}
+	return nil
+}
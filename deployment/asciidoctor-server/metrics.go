@@ -0,0 +1,138 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// convertDurationBuckets spans 100ms-5m, the realistic range from a short HTML5
+// render to a large PDF/EPUB conversion running up against ASCIIDOCTOR_CONVERSION_TIMEOUT.
+var convertDurationBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 180, 300}
+
+// convertBytesBuckets covers a small AsciiDoc snippet up through multi-megabyte
+// artifacts, well past MaxContentSize on the input side.
+var convertBytesBuckets = prometheus.ExponentialBuckets(1024, 4, 8) // 1KiB .. 64MiB
+
+// Metrics holds the Prometheus collectors instrumented at the same points
+// s.logger.Info/Error already fire in handleConvert and recoveryMiddleware, so the
+// metrics never drift out of sync with what the logs already say happened. Nil
+// receiver methods are no-ops, so callers don't need to guard every call site when
+// metrics are disabled (see Server.metrics in main.go).
+type Metrics struct {
+	registry *prometheus.Registry
+
+	ConvertRequestsTotal    *prometheus.CounterVec
+	ConvertDurationSeconds  *prometheus.HistogramVec
+	ConvertInputBytes       prometheus.Histogram
+	ConvertOutputBytes      prometheus.Histogram
+	ConvertTimeoutsTotal    *prometheus.CounterVec
+	ValidationFailuresTotal prometheus.Counter
+	HTTPRequestsInFlight    prometheus.Gauge
+	PanicRecoveriesTotal    prometheus.Counter
+}
+
+// NewMetrics registers all collectors against a fresh, private registry - not the
+// default global one - so tests and multiple Server instances in the same process
+// don't collide on collector registration.
+func NewMetrics() *Metrics {
+	registry := prometheus.NewRegistry()
+	factory := promauto.With(registry)
+
+	return &Metrics{
+		registry: registry,
+		ConvertRequestsTotal: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "asciidoctor_convert_requests_total",
+			Help: "Total conversion requests, by output format and outcome status.",
+		}, []string{"format", "status"}),
+		ConvertDurationSeconds: factory.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "asciidoctor_convert_duration_seconds",
+			Help:    "Conversion duration in seconds, by output format.",
+			Buckets: convertDurationBuckets,
+		}, []string{"format"}),
+		ConvertInputBytes: factory.NewHistogram(prometheus.HistogramOpts{
+			Name:    "asciidoctor_convert_input_bytes",
+			Help:    "Size of submitted AsciiDoc content in bytes.",
+			Buckets: convertBytesBuckets,
+		}),
+		ConvertOutputBytes: factory.NewHistogram(prometheus.HistogramOpts{
+			Name:    "asciidoctor_convert_output_bytes",
+			Help:    "Size of the produced conversion artifact in bytes.",
+			Buckets: convertBytesBuckets,
+		}),
+		ConvertTimeoutsTotal: factory.NewCounterVec(prometheus.CounterOpts{
+			Name: "asciidoctor_convert_timeouts_total",
+			Help: "Total conversions that exceeded ASCIIDOCTOR_CONVERSION_TIMEOUT, by output format.",
+		}, []string{"format"}),
+		ValidationFailuresTotal: factory.NewCounter(prometheus.CounterOpts{
+			Name: "asciidoctor_convert_validation_failures_total",
+			Help: "Total requests rejected by ValidateAndFixAsciiDoc for invalid AsciiDoc syntax.",
+		}),
+		HTTPRequestsInFlight: factory.NewGauge(prometheus.GaugeOpts{
+			Name: "asciidoctor_http_requests_in_flight",
+			Help: "HTTP requests currently being handled.",
+		}),
+		PanicRecoveriesTotal: factory.NewCounter(prometheus.CounterOpts{
+			Name: "asciidoctor_panic_recoveries_total",
+			Help: "Total panics recovered by recoveryMiddleware.",
+		}),
+	}
+}
+
+// Handler serves the registry in the Prometheus text exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveConvert records the outcome of one handleConvert call. status is one of
+// "success", "timeout", or "error", matching the branches handleConvert already logs.
+func (m *Metrics) ObserveConvert(format, status string, duration float64) {
+	if m == nil {
+		return
+	}
+	m.ConvertRequestsTotal.WithLabelValues(format, status).Inc()
+	if status == "success" {
+		m.ConvertDurationSeconds.WithLabelValues(format).Observe(duration)
+	}
+	if status == "timeout" {
+		m.ConvertTimeoutsTotal.WithLabelValues(format).Inc()
+	}
+}
+
+func (m *Metrics) ObserveConvertSizes(inputBytes, outputBytes int64) {
+	if m == nil {
+		return
+	}
+	m.ConvertInputBytes.Observe(float64(inputBytes))
+	m.ConvertOutputBytes.Observe(float64(outputBytes))
+}
+
+func (m *Metrics) IncValidationFailure() {
+	if m == nil {
+		return
+	}
+	m.ValidationFailuresTotal.Inc()
+}
+
+func (m *Metrics) IncPanicRecovery() {
+	if m == nil {
+		return
+	}
+	m.PanicRecoveriesTotal.Inc()
+}
+
+func (m *Metrics) IncInFlight() {
+	if m == nil {
+		return
+	}
+	m.HTTPRequestsInFlight.Inc()
+}
+
+func (m *Metrics) DecInFlight() {
+	if m == nil {
+		return
+	}
+	m.HTTPRequestsInFlight.Dec()
+}
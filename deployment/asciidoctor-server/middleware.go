@@ -5,7 +5,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"runtime/debug"
 	"strings"
@@ -30,6 +29,9 @@ func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 			statusCode:     http.StatusOK,
 		}
 
+		s.metrics.IncInFlight()
+		defer s.metrics.DecInFlight()
+
 		// Log request
 		s.logger.Info("http", fmt.Sprintf("%s %s", r.Method, r.URL.Path), map[string]interface{}{
 			"request_id":  requestID,
@@ -92,6 +94,8 @@ func (s *Server) recoveryMiddleware(next http.Handler) http.Handler {
 				// Get stack trace
 				stack := string(debug.Stack())
 
+				s.metrics.IncPanicRecovery()
+
 				// Log panic with full details
 				s.logger.Error("http", "Panic recovered", fmt.Errorf("%v", err), map[string]interface{}{
 					"request_id":  requestID,
@@ -132,57 +136,137 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
-// compressionMiddleware compresses responses with gzip when supported
+// compressionMiddleware compresses responses with gzip when the client supports it,
+// the response's Content-Type is one of s.gzipTypes, and the body turns out to be at
+// least s.gzipMinBytes - small JSON error bodies aren't worth the CPU, and a client
+// that already declined gzip shouldn't pay for one either. gzip.Writers are pooled
+// (keyed by s.gzipLevel, fixed at startup) instead of allocated per request.
 func (s *Server) compressionMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Check if client supports gzip
-		acceptEncoding := r.Header.Get("Accept-Encoding")
-		if !strings.Contains(acceptEncoding, "gzip") {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
 			next.ServeHTTP(w, r)
 			return
 		}
 
-		// Check if response should be compressed
-		contentType := ""
-		if ct := w.Header().Get("Content-Type"); ct != "" {
-			contentType = ct
-		}
-
-		// Only compress text-based content
-		shouldCompress := strings.HasPrefix(contentType, "text/") ||
-			strings.HasPrefix(contentType, "application/json") ||
-			strings.HasPrefix(contentType, "application/javascript") ||
-			contentType == ""
-
-		if !shouldCompress {
+		// Server-Sent Events need every frame flushed to the client as it's written;
+		// gzipResponseWriter buffers up to gzipMinBytes and doesn't implement
+		// http.Flusher, which would turn "live" progress into one delayed burst.
+		if r.URL.Query().Get("stream") == "sse" {
 			next.ServeHTTP(w, r)
 			return
 		}
 
-		// Create gzip writer
-		gz := gzip.NewWriter(w)
-		defer gz.Close()
-
-		// Set headers
-		w.Header().Set("Content-Encoding", "gzip")
-		w.Header().Set("Vary", "Accept-Encoding")
-
-		// Wrap response writer
 		gzw := &gzipResponseWriter{
 			ResponseWriter: w,
-			Writer:        gz,
+			server:         s,
+			statusCode:     http.StatusOK,
 		}
+		defer gzw.Close()
 
 		next.ServeHTTP(gzw, r)
 	})
 }
 
-// gzipResponseWriter wraps http.ResponseWriter with gzip compression
+// gzipResponseWriter buffers the first s.gzipMinBytes of the response so the
+// compress/don't-compress decision can be made once the handler's Content-Type and
+// roughly its size are known, rather than up front when neither is set yet.
 type gzipResponseWriter struct {
 	http.ResponseWriter
-	Writer io.Writer
+	server *Server
+
+	statusCode int
+	buf        []byte
+	decided    bool
+	compress   bool
+	gz         *gzip.Writer
+}
+
+func (gzw *gzipResponseWriter) WriteHeader(code int) {
+	gzw.statusCode = code
 }
 
 func (gzw *gzipResponseWriter) Write(b []byte) (int, error) {
-	return gzw.Writer.Write(b)
+	if gzw.decided {
+		if gzw.compress {
+			return gzw.gz.Write(b)
+		}
+		return gzw.ResponseWriter.Write(b)
+	}
+
+	gzw.buf = append(gzw.buf, b...)
+	if len(gzw.buf) < gzw.server.gzipMinBytes {
+		return len(b), nil
+	}
+
+	if err := gzw.flush(gzw.shouldCompressType()); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// shouldCompressType checks the handler's Content-Type (set before any Write, per
+// net/http convention) against s.gzipTypes, defaulting to compress when no
+// Content-Type was set at all - matching this middleware's prior behavior.
+func (gzw *gzipResponseWriter) shouldCompressType() bool {
+	contentType := gzw.Header().Get("Content-Type")
+	if contentType == "" {
+		return true
+	}
+	for _, t := range gzw.server.gzipTypes {
+		if strings.HasPrefix(contentType, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// flush makes the compress/don't-compress decision final, writes the response header
+// (deferred until now so Content-Length can be stripped when compression engages,
+// since gzip changes the body size handleConvert already computed it from), and
+// drains the buffered bytes through whichever path was chosen.
+func (gzw *gzipResponseWriter) flush(compress bool) error {
+	gzw.decided = true
+	gzw.compress = compress
+
+	if compress {
+		gzw.Header().Del("Content-Length")
+		gzw.Header().Set("Content-Encoding", "gzip")
+		gzw.Header().Set("Vary", "Accept-Encoding")
+		gzw.gz = gzw.server.gzipPool.Get().(*gzip.Writer)
+		gzw.gz.Reset(gzw.ResponseWriter)
+	}
+
+	gzw.ResponseWriter.WriteHeader(gzw.statusCode)
+
+	buf := gzw.buf
+	gzw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if compress {
+		_, err := gzw.gz.Write(buf)
+		return err
+	}
+	_, err := gzw.ResponseWriter.Write(buf)
+	return err
+}
+
+// Close finalizes the response: bodies that never reached gzipMinBytes are decided
+// (and flushed) here for the first time, gated on the buffered size as well as
+// Content-Type so a short body isn't compressed just because its type matched, and
+// an engaged gzip.Writer is closed and returned to the pool.
+func (gzw *gzipResponseWriter) Close() error {
+	if !gzw.decided {
+		compress := gzw.shouldCompressType() && len(gzw.buf) >= gzw.server.gzipMinBytes
+		if err := gzw.flush(compress); err != nil {
+			return err
+		}
+	}
+	if gzw.gz == nil {
+		return nil
+	}
+	err := gzw.gz.Close()
+	gzw.server.gzipPool.Put(gzw.gz)
+	gzw.gz = nil
+	return err
 }
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// RetryConfig configures the exponential-backoff retry loop handleConvert wraps
+// convertFn in (see withRetry). Disabled by default: most transient asciidoctor
+// failures are rare enough that operators should opt in deliberately rather than
+// have every request silently eat extra latency on the timeout budget.
+type RetryConfig struct {
+	Enabled         bool
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	MaxElapsedTime  time.Duration
+	Multiplier      float64
+}
+
+// retriableStderrSignatures are exec.ExitError stderr substrings known to indicate a
+// transient asciidoctor/ebook-convert failure rather than a real conversion problem -
+// temp-file races, ImageMagick/PlantUML JVM startup, or the subprocess getting
+// resource-starved under load.
+var retriableStderrSignatures = []string{
+	"resource temporarily unavailable",
+	"cannot allocate memory",
+	"broken pipe",
+}
+
+// isRetriableError classifies a convertFn error as safe to retry. Timeouts are
+// terminal - retrying after context.DeadlineExceeded just wastes whatever's left of
+// the budget on an attempt that will fail identically. AsciiDoc syntax errors are
+// terminal too, but in practice handleConvert already rejects those with 400 via
+// ValidateAndFixAsciiDoc before convertFn (and the retry loop) ever runs.
+func isRetriableError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		stderr := strings.ToLower(string(exitErr.Stderr))
+		for _, sig := range retriableStderrSignatures {
+			if strings.Contains(stderr, sig) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var pathErr *os.PathError
+	if errors.As(err, &pathErr) {
+		return true
+	}
+
+	return false
+}
+
+// withRetry runs fn, retrying with exponential backoff while cfg.Enabled and the
+// error is isRetriableError. Each attempt gets a fresh context derived from
+// parentCtx's deadline rather than the previous attempt's, so a slow first attempt
+// doesn't leave a second attempt with less budget than parentCtx actually has left.
+// It returns the number of retries performed (0 on a first-attempt success) so the
+// caller can surface it in its completion log line - a rising retry count is the
+// signal that flakiness is being masked rather than fixed.
+func withRetry(parentCtx context.Context, cfg RetryConfig, logger *Logger, fields map[string]interface{}, fn func(context.Context) (*ConvertResult, error)) (*ConvertResult, int, error) {
+	if !cfg.Enabled {
+		result, err := fn(parentCtx)
+		return result, 0, err
+	}
+
+	deadline, hasDeadline := parentCtx.Deadline()
+	start := time.Now()
+	interval := cfg.InitialInterval
+
+	for attempt := 0; ; attempt++ {
+		ctx := parentCtx
+		var cancel context.CancelFunc
+		if hasDeadline {
+			ctx, cancel = context.WithDeadline(parentCtx, deadline)
+		}
+		result, err := fn(ctx)
+		if cancel != nil {
+			cancel()
+		}
+
+		if err == nil {
+			return result, attempt, nil
+		}
+		if !isRetriableError(err) {
+			return nil, attempt, err
+		}
+		if cfg.MaxElapsedTime > 0 && time.Since(start) >= cfg.MaxElapsedTime {
+			return nil, attempt, err
+		}
+
+		retryFields := make(map[string]interface{}, len(fields)+3)
+		for k, v := range fields {
+			retryFields[k] = v
+		}
+		retryFields["attempt"] = attempt + 1
+		retryFields["next_backoff"] = interval.String()
+		retryFields["error"] = err.Error()
+		logger.Info("converter", "Retrying transient conversion failure", retryFields)
+
+		select {
+		case <-time.After(interval):
+		case <-parentCtx.Done():
+			return nil, attempt, err
+		}
+
+		interval = time.Duration(float64(interval) * cfg.Multiplier)
+		if interval > cfg.MaxInterval {
+			interval = cfg.MaxInterval
+		}
+	}
+}
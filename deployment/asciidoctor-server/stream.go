@@ -0,0 +1,312 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ConvertEventType names the stages ConvertStream reports on its channel.
+type ConvertEventType string
+
+const (
+	EventImageDownloadStarted    ConvertEventType = "image_download_started"
+	EventImageDownloaded         ConvertEventType = "image_downloaded"
+	EventAsciidoctorStarted      ConvertEventType = "asciidoctor_started"
+	EventAsciidoctorStdoutLine   ConvertEventType = "asciidoctor_stdout_line"
+	EventEmbeddingStarted        ConvertEventType = "embedding_started"
+	EventKindleConversionStarted ConvertEventType = "kindle_conversion_started"
+	EventWarning                 ConvertEventType = "warning"
+	EventCompleted               ConvertEventType = "completed"
+	EventFailed                  ConvertEventType = "failed"
+)
+
+// ConvertEvent is one step of progress from ConvertStream. Only the fields relevant to
+// Type are populated - e.g. URL/Bytes on an image event, Line on a stdout-line event,
+// Message on a non-fatal Warning.
+type ConvertEvent struct {
+	Type ConvertEventType `json:"type"`
+
+	URL     string `json:"url,omitempty"`
+	Bytes   int64  `json:"bytes,omitempty"`
+	Line    string `json:"line,omitempty"`
+	Message string `json:"message,omitempty"`
+
+	Result *ConvertResult `json:"result,omitempty"`
+	Err    error          `json:"-"`
+}
+
+// emitEvent sends evt on ch, giving up if ctx is done instead of blocking forever on a
+// consumer that stopped reading (e.g. a web UI that navigated away mid-conversion).
+func emitEvent(ctx context.Context, ch chan<- ConvertEvent, evt ConvertEvent) {
+	select {
+	case ch <- evt:
+	case <-ctx.Done():
+	}
+}
+
+// streamFormats maps the formats ConvertStream drives through the piped-exec path to
+// their asciidoctor backend name and output extension.
+var streamFormats = map[string]struct{ backend, extension string }{
+	"epub":     {"epub3", "epub"},
+	"docbook5": {"docbook5", "xml"},
+	"pdf":      {"pdf", "pdf"},
+}
+
+// ConvertStream runs a conversion the same way ConvertToEPUB/ConvertToHTML5/etc. do, but
+// reports progress on the returned channel instead of blocking until the whole pipeline
+// finishes - letting a caller (e.g. a web UI) show live status for long-running EPUB/MOBI
+// conversions instead of an all-or-nothing wait. The channel always ends with exactly one
+// of Completed or Failed, followed by the channel being closed; callers should keep
+// draining until it closes rather than stopping at the first terminal event.
+//
+// ConvertStream always does a fresh conversion via the direct-exec asciidoctor path - it
+// does not consult or populate the conversion cache, and bypasses the worker pool and any
+// sandboxed Backend, since neither has per-line stdout to report.
+//
+// jobID names the disk-backed job directory (<TempDir>/jobs/<jobID>) that holds the
+// artifact for the rest of its life - see jobWorkDir in jobs.go. Both callers
+// (JobQueue.run and handleConvertSSE) already have a job ID in hand before the
+// conversion starts, or reserve one via NewJobID for this purpose.
+func (c *Converter) ConvertStream(ctx context.Context, req *ConvertRequest, format, jobID string) (<-chan ConvertEvent, error) {
+	if _, ok := c.formatBackends()[format]; !ok {
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+
+	ch := make(chan ConvertEvent, 8)
+	go func() {
+		defer close(ch)
+
+		var result *ConvertResult
+		var err error
+		switch format {
+		case "html5":
+			result, err = c.streamHTML5(ctx, ch, req, jobID)
+		case "mobi", "azw3":
+			result, err = c.streamKindle(ctx, ch, req, format)
+		default:
+			spec := streamFormats[format]
+			result, err = c.streamDirect(ctx, ch, req, spec.backend, spec.extension, buildConversionAttributes(req), jobID)
+		}
+
+		if err != nil {
+			emitEvent(ctx, ch, ConvertEvent{Type: EventFailed, Err: err})
+			return
+		}
+		emitEvent(ctx, ch, ConvertEvent{Type: EventCompleted, Result: result})
+	}()
+
+	return ch, nil
+}
+
+// streamDirect runs asciidoctor for a single-pass format (epub/docbook5/pdf) with piped
+// stdout/stderr, emitting AsciidoctorStarted/AsciidoctorStdoutLine events as it goes.
+//
+// Unlike the synchronous convert path, workDir is the job's disk-backed directory
+// (<TempDir>/jobs/<jobID>, see jobWorkDir in jobs.go) and is NOT removed on success:
+// the result this returns is handed to a Job (JobQueue.run or RegisterResult), and it's
+// the JobQueue's TTL sweeper that removes workDir once the artifact has been downloaded
+// or expires (see sweepExpired in jobs.go). Removing it here via defer, like the
+// synchronous path does, would delete the file out from under the consumer before it
+// ever sees the result. workDir is only cleaned up directly on an error return, since
+// then there's no Job to track it.
+func (c *Converter) streamDirect(ctx context.Context, ch chan<- ConvertEvent, req *ConvertRequest, backendName, extension string, attrs map[string]string, jobID string) (*ConvertResult, error) {
+	workDir, err := jobWorkDir(c.tempDir, jobID)
+	if err != nil {
+		return nil, err
+	}
+	ok := false
+	defer func() {
+		if !ok {
+			os.RemoveAll(workDir)
+		}
+	}()
+
+	inputPath := filepath.Join(workDir, "input.adoc")
+	if err := os.WriteFile(inputPath, []byte(req.Content), FileModeFile); err != nil {
+		return nil, fmt.Errorf("failed to write content to temp file: %w", err)
+	}
+	outputPath := filepath.Join(workDir, fmt.Sprintf("output.%s", extension))
+
+	if err := c.runAsciidoctorStreaming(ctx, ch, backendName, workDir, inputPath, outputPath, attrs); err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("output file not created at %s: %w", outputPath, err)
+	}
+	if info.Size() == 0 {
+		return nil, fmt.Errorf("output file is empty")
+	}
+
+	result, err := c.finalizeConvertResult(outputPath, getMimeType(extension), info.Size())
+	if err != nil {
+		return nil, err
+	}
+	ok = true
+	return result, nil
+}
+
+// streamHTML5 mirrors convertHTML5Uncached's direct-exec path, but wires the image
+// handler and asciidoctor invocation to emit progress on ch.
+//
+// As with streamDirect, workDir is the job's disk-backed directory and survives a
+// successful return - cleanup happens later through the Job lifecycle, not a
+// function-scoped defer - and is only removed directly here on an error return.
+func (c *Converter) streamHTML5(ctx context.Context, ch chan<- ConvertEvent, req *ConvertRequest, jobID string) (*ConvertResult, error) {
+	workDir, err := jobWorkDir(c.tempDir, jobID)
+	if err != nil {
+		return nil, err
+	}
+	ok := false
+	defer func() {
+		if !ok {
+			os.RemoveAll(workDir)
+		}
+	}()
+
+	imageHandler := NewImageHandler(c.logger, workDir, c.imageCache).WithEvents(ch).WithUploadedAssets(req.UploadedAssets)
+	if err := imageHandler.ProcessImagesForHTML(ctx, req.Content); err != nil {
+		c.logger.Warn("converter", "Failed to process some images, continuing with conversion", map[string]interface{}{
+			"error": err.Error(),
+		})
+		emitEvent(ctx, ch, ConvertEvent{Type: EventWarning, Message: err.Error()})
+	}
+	if req.ImageOptions != nil {
+		imageHandler.TranscodeImages(*req.ImageOptions)
+	}
+	// Downloaded source images are always cleaned up once embedding is done, win or
+	// lose - the HTML output embeds them as base64, so nothing past this point depends
+	// on workDir/images still existing.
+	defer imageHandler.Cleanup()
+
+	inputPath := filepath.Join(workDir, "input.adoc")
+	if err := os.WriteFile(inputPath, []byte(req.Content), FileModeFile); err != nil {
+		return nil, fmt.Errorf("failed to write content to temp file: %w", err)
+	}
+	outputPath := filepath.Join(workDir, "output.html")
+
+	attrs := buildConversionAttributes(req)
+	attrs["standalone"] = ""
+	attrs["imagesdir"] = "images"
+	if err := c.runAsciidoctorStreaming(ctx, ch, "html5", workDir, inputPath, outputPath, attrs); err != nil {
+		return nil, err
+	}
+
+	emitEvent(ctx, ch, ConvertEvent{Type: EventEmbeddingStarted})
+	result, err := c.finishHTML5Output(outputPath, req, imageHandler, 0)
+	if err != nil {
+		return nil, err
+	}
+	ok = true
+	return result, nil
+}
+
+// streamKindle mirrors convertViaEPUBUncached, emitting KindleConversionStarted around
+// whichever path (native Go writer or Calibre ebook-convert) actually produces the
+// Kindle artifact. The EPUB intermediate still goes through the non-streaming
+// ConvertToEPUB/ConvertToEPUBNative - it's usually cache-backed and fast enough that
+// fine-grained events add little for this step.
+func (c *Converter) streamKindle(ctx context.Context, ch chan<- ConvertEvent, req *ConvertRequest, kindleFormat string) (*ConvertResult, error) {
+	if c.config.NativeKindle {
+		emitEvent(ctx, ch, ConvertEvent{Type: EventKindleConversionStarted})
+		result, err := c.convertToKindleNativeUncached(ctx, req, kindleFormat)
+		if err == nil {
+			return result, nil
+		}
+		c.logger.Warn("converter", fmt.Sprintf("Native %s conversion failed, falling back to Calibre ebook-convert", kindleFormat), map[string]interface{}{
+			"error": err.Error(),
+		})
+	}
+
+	emitEvent(ctx, ch, ConvertEvent{Type: EventAsciidoctorStarted})
+	epubResult, err := c.convertToEPUBForKindle(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate EPUB for %s conversion: %w", kindleFormat, err)
+	}
+	defer os.Remove(epubResult.FilePath)
+
+	emitEvent(ctx, ch, ConvertEvent{Type: EventKindleConversionStarted})
+	return c.runEbookConvert(ctx, epubResult, kindleFormat)
+}
+
+// runAsciidoctorStreaming runs asciidoctor with piped stdout/stderr instead of
+// CombinedOutput, emitting AsciidoctorStarted up front and an AsciidoctorStdoutLine per
+// line of output as it arrives - asciidoctor prints its own warnings ("WARNING: ...")
+// and errors to stderr line-by-line, so this is what lets a caller surface them as they
+// happen instead of only after the whole process exits.
+func (c *Converter) runAsciidoctorStreaming(ctx context.Context, ch chan<- ConvertEvent, backendName, workDir, inputPath, outputPath string, attrs map[string]string) error {
+	args := []string{"-b", backendName, "-D", workDir, "-o", filepath.Base(outputPath)}
+	args = append(args, asciidoctorArgsFromAttributes(attrs)...)
+	args = append(args, filepath.Base(inputPath))
+
+	cmd := buildAsciidoctorCmd(ctx, c.asciidoctorPath, c.config, args)
+	cmd.Dir = workDir
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open asciidoctor stdout: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open asciidoctor stderr: %w", err)
+	}
+
+	c.logger.Info("converter", fmt.Sprintf("Starting streaming %s conversion", backendName), map[string]interface{}{
+		"operation":   "conversion",
+		"backend":     backendName,
+		"input_file":  inputPath,
+		"output_file": outputPath,
+		"work_dir":    workDir,
+	})
+
+	emitEvent(ctx, ch, ConvertEvent{Type: EventAsciidoctorStarted})
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start asciidoctor: %w", err)
+	}
+
+	var mu sync.Mutex
+	var lines []string
+	var wg sync.WaitGroup
+	streamLines := func(r io.Reader) {
+		defer wg.Done()
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			line := scanner.Text()
+			mu.Lock()
+			lines = append(lines, line)
+			mu.Unlock()
+			emitEvent(ctx, ch, ConvertEvent{Type: EventAsciidoctorStdoutLine, Line: line})
+		}
+	}
+	wg.Add(2)
+	go streamLines(stdout)
+	go streamLines(stderr)
+	wg.Wait()
+
+	if err := cmd.Wait(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("conversion timeout after %s: %w", c.timeout, err)
+		}
+		mu.Lock()
+		output := strings.Join(lines, "\n")
+		mu.Unlock()
+		c.logger.Error("converter", fmt.Sprintf("%s streaming conversion failed", backendName), err, map[string]interface{}{
+			"error_type":     "conversion_failed",
+			"component":      "converter",
+			"operation":      "conversion",
+			"backend":        backendName,
+			"command_output": output,
+		})
+		return fmt.Errorf("conversion failed: %w (output: %s)", err, output)
+	}
+
+	return nil
+}
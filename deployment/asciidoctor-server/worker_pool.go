@@ -0,0 +1,303 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// asciidoctorWorkerScript is a small Ruby server that loops reading one JSON job per
+// line from stdin and writes one JSON result per line to stdout, keeping the
+// Asciidoctor/Ruby runtime resident instead of paying load time on every request.
+const asciidoctorWorkerScript = `#!/usr/bin/env ruby
+require 'asciidoctor'
+require 'asciidoctor-epub3'
+require 'asciidoctor-pdf'
+require 'json'
+
+STDOUT.sync = true
+
+while (line = STDIN.gets)
+  job = JSON.parse(line)
+  begin
+    Asciidoctor.convert_file(
+      job['input'],
+      to_file: job['output'],
+      backend: job['backend'],
+      safe: :unsafe,
+      attributes: job['attributes'] || {}
+    )
+    puts JSON.generate({ 'ok' => true })
+  rescue => e
+    puts JSON.generate({ 'ok' => false, 'error' => e.message })
+  end
+end
+`
+
+// WorkerJob describes a single conversion job handed to a worker process
+type WorkerJob struct {
+	InputPath  string            `json:"input"`
+	OutputPath string            `json:"output"`
+	Backend    string            `json:"backend"`
+	Attributes map[string]string `json:"attributes"`
+}
+
+// workerResult is the JSON line a worker sends back after processing a job
+type workerResult struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+// worker wraps one long-lived `ruby asciidoctor_worker.rb` process
+type worker struct {
+	id     int
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Scanner
+	mu     sync.Mutex
+}
+
+// WorkerPool manages a fixed number of persistent Asciidoctor worker processes so
+// conversions avoid paying Ruby/Bundler/Asciidoctor startup cost on every request.
+type WorkerPool struct {
+	logger     *Logger
+	cfg        Config
+	scriptPath string
+	rubyBin    []string // command + args to launch the worker script, e.g. ["bundle", "exec", "ruby"]
+	jobTimeout time.Duration
+
+	workers chan *worker
+	size    int
+
+	busy     int32
+	restarts int32
+	nextID   int32
+}
+
+// NewWorkerPool writes the worker script to cfg.TempDir and spawns cfg.WorkerPoolSize
+// persistent Asciidoctor processes. Callers should fall back to the one-shot exec path
+// if this returns an error - that is the expected outcome on hosts without bundle/ruby.
+func NewWorkerPool(logger *Logger, cfg Config, asciidoctorPath string) (*WorkerPool, error) {
+	if cfg.WorkerPoolSize <= 0 {
+		return nil, fmt.Errorf("worker pool disabled (ASCIIDOCTOR_WORKER_POOL_SIZE=0)")
+	}
+
+	scriptDir := filepath.Join(cfg.TempDir, "asciidoctor-server")
+	if err := os.MkdirAll(scriptDir, FileModeDir); err != nil {
+		return nil, fmt.Errorf("failed to create worker script directory: %w", err)
+	}
+	scriptPath := filepath.Join(scriptDir, "asciidoctor_worker.rb")
+	if err := os.WriteFile(scriptPath, []byte(asciidoctorWorkerScript), FileModeFile); err != nil {
+		return nil, fmt.Errorf("failed to write worker script: %w", err)
+	}
+
+	rubyBin, err := resolveWorkerRubyCommand(cfg, asciidoctorPath)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &WorkerPool{
+		logger:     logger,
+		cfg:        cfg,
+		scriptPath: scriptPath,
+		rubyBin:    rubyBin,
+		jobTimeout: cfg.ConversionTimeout,
+		workers:    make(chan *worker, cfg.WorkerPoolSize),
+		size:       cfg.WorkerPoolSize,
+	}
+
+	for i := 0; i < cfg.WorkerPoolSize; i++ {
+		w, err := p.spawnWorker()
+		if err != nil {
+			p.Shutdown()
+			return nil, fmt.Errorf("failed to spawn worker %d/%d: %w", i+1, cfg.WorkerPoolSize, err)
+		}
+		p.workers <- w
+	}
+
+	logger.Info("worker_pool", "Asciidoctor worker pool started", map[string]interface{}{
+		"pool_size": cfg.WorkerPoolSize,
+		"command":   rubyBin,
+	})
+
+	return p, nil
+}
+
+// resolveWorkerRubyCommand mirrors findAsciidoctor's bundle-exec-first resolution so the
+// worker script runs with the same gem environment as the one-shot CLI would.
+func resolveWorkerRubyCommand(cfg Config, asciidoctorPath string) ([]string, error) {
+	if bundleCmd, err := exec.LookPath("bundle"); err == nil {
+		return []string{bundleCmd, "exec", "ruby"}, nil
+	}
+	if rubyCmd, err := exec.LookPath("ruby"); err == nil {
+		return []string{rubyCmd}, nil
+	}
+	return nil, fmt.Errorf("neither bundle nor ruby found in PATH, cannot start worker pool")
+}
+
+func (p *WorkerPool) spawnWorker() (*worker, error) {
+	id := int(atomic.AddInt32(&p.nextID, 1))
+
+	args := append(append([]string{}, p.rubyBin[1:]...), p.scriptPath)
+	cmd := exec.Command(p.rubyBin[0], args...)
+	if absGemfile, err := filepath.Abs(p.cfg.BundleGemfile); err == nil {
+		if _, statErr := os.Stat(absGemfile); statErr == nil {
+			cmd.Env = append(os.Environ(),
+				"BUNDLE_GEMFILE="+absGemfile,
+				"BUNDLE_PATH="+p.cfg.BundlePath,
+			)
+		}
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open worker stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open worker stdout: %w", err)
+	}
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start worker process: %w", err)
+	}
+
+	return &worker{
+		id:     id,
+		cmd:    cmd,
+		stdin:  stdin,
+		stdout: bufio.NewScanner(stdout),
+	}, nil
+}
+
+func (w *worker) kill() {
+	w.stdin.Close()
+	if w.cmd.Process != nil {
+		w.cmd.Process.Kill()
+	}
+	w.cmd.Wait()
+}
+
+// Convert acquires a worker, submits job, and waits for its JSON result, restarting the
+// worker in place (without tearing down the rest of the pool) on crash or timeout.
+func (p *WorkerPool) Convert(ctx context.Context, job WorkerJob) error {
+	w, err := p.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	atomic.AddInt32(&p.busy, 1)
+	defer atomic.AddInt32(&p.busy, -1)
+
+	jobCtx, cancel := context.WithTimeout(ctx, p.jobTimeout)
+	defer cancel()
+
+	type jobOutcome struct {
+		result workerResult
+		err    error
+	}
+	done := make(chan jobOutcome, 1)
+
+	go func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+
+		payload, err := json.Marshal(job)
+		if err != nil {
+			done <- jobOutcome{err: fmt.Errorf("failed to encode job: %w", err)}
+			return
+		}
+		if _, err := w.stdin.Write(append(payload, '\n')); err != nil {
+			done <- jobOutcome{err: fmt.Errorf("failed to write job to worker: %w", err)}
+			return
+		}
+		if !w.stdout.Scan() {
+			done <- jobOutcome{err: fmt.Errorf("worker closed stdout: %w", w.stdout.Err())}
+			return
+		}
+		var result workerResult
+		if err := json.Unmarshal(w.stdout.Bytes(), &result); err != nil {
+			done <- jobOutcome{err: fmt.Errorf("failed to decode worker result: %w", err)}
+			return
+		}
+		done <- jobOutcome{result: result}
+	}()
+
+	select {
+	case outcome := <-done:
+		if outcome.err != nil {
+			p.replaceWorker(w)
+			return outcome.err
+		}
+		p.workers <- w
+		if !outcome.result.OK {
+			return fmt.Errorf("conversion failed: %s", outcome.result.Error)
+		}
+		return nil
+	case <-jobCtx.Done():
+		// Kill the stuck worker and replace it; the pool keeps running at full size.
+		p.replaceWorker(w)
+		return fmt.Errorf("worker job timed out after %s: %w", p.jobTimeout, jobCtx.Err())
+	}
+}
+
+// replaceWorker kills a crashed/stuck worker and spawns a fresh one in its place so the
+// pool's capacity never shrinks permanently due to a single bad job.
+func (p *WorkerPool) replaceWorker(w *worker) {
+	w.kill()
+	atomic.AddInt32(&p.restarts, 1)
+
+	replacement, err := p.spawnWorker()
+	if err != nil {
+		p.logger.Error("worker_pool", "Failed to restart crashed worker", err, map[string]interface{}{
+			"error_type": "worker_restart_failed",
+			"worker_id":  w.id,
+		})
+		// Pool is now one worker short; acquire() will block longer under load
+		// until a future call to Shutdown/NewWorkerPool restores capacity.
+		return
+	}
+	p.logger.Warn("worker_pool", "Restarted worker after crash/timeout", map[string]interface{}{
+		"old_worker_id": w.id,
+		"new_worker_id": replacement.id,
+		"restarts":      atomic.LoadInt32(&p.restarts),
+	})
+	p.workers <- replacement
+}
+
+func (p *WorkerPool) acquire(ctx context.Context) (*worker, error) {
+	select {
+	case w := <-p.workers:
+		return w, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Stats reports busy/idle worker counts and lifetime restarts, surfaced through Logger.
+func (p *WorkerPool) Stats() map[string]interface{} {
+	busy := int(atomic.LoadInt32(&p.busy))
+	return map[string]interface{}{
+		"pool_size": p.size,
+		"busy":      busy,
+		"idle":      p.size - busy,
+		"restarts":  int(atomic.LoadInt32(&p.restarts)),
+	}
+}
+
+// Shutdown kills every worker process in the pool. Safe to call on a partially
+// initialized pool.
+func (p *WorkerPool) Shutdown() {
+	close(p.workers)
+	for w := range p.workers {
+		w.kill()
+	}
+}